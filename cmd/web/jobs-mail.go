@@ -194,6 +194,7 @@ func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
 	smtpClient, err := server.Connect()
 	if err != nil {
 		log.Println(err)
+		return
 	}
 
 	//Create email message object
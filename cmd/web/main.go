@@ -7,12 +7,12 @@ import (
 	"os"
 	"runtime"
 	"time"
+	"vigilate/internal/broadcast"
 	"vigilate/internal/config"
 	"vigilate/internal/handlers"
 	"vigilate/internal/models"
 
 	"github.com/alexedwards/scs/v2"
-	"github.com/pusher/pusher-http-go"
 )
 
 //This is the main entry point for the Vigilate application
@@ -29,8 +29,11 @@ var repo *handlers.DBRepo
 var session *scs.SessionManager
 var preferenceMap map[string]string
 
-// Websocket client (Pusher)
-var wsClient pusher.Client
+// Websocket broadcaster (built-in hub or Pusher)
+var wsClient broadcast.Broadcaster
+
+// Built-in websocket hub, nil when broadcasting through Pusher
+var wsHub *broadcast.Hub
 
 const vigilateVersion = "1.0.0"
 const maxWorkerPoolSize = 5
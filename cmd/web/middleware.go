@@ -56,6 +56,7 @@ func NoSurf(next http.Handler) http.Handler {
 	//Exempt certain routes from CSRF checks
 	csrfHandler.ExemptPath("/pusher/auth")
 	csrfHandler.ExemptPath("/pusher/hook")
+	csrfHandler.ExemptRegexp("^/heartbeat/")
 
 	//Configure CSRF cookie
 	csrfHandler.SetBaseCookie(http.Cookie{
@@ -26,6 +26,15 @@ func routes() http.Handler {
 
 	mux.Get("/user/logout", handlers.Repo.Logout)
 
+	// built-in websocket hub; pusher-js connects to /ws/app/{key}
+	if wsHub != nil {
+		mux.Handle("/ws/app/{key}", wsHub)
+	}
+
+	// heartbeat pings from monitored jobs; the token authenticates them
+	mux.HandleFunc("/heartbeat/{token}", handlers.Repo.Heartbeat)
+	mux.HandleFunc("/heartbeat/{token}/{kind}", handlers.Repo.Heartbeat)
+
 	mux.Route("/pusher", func(mux chi.Router) {
 		mux.Use(Auth)
 		mux.Post("/auth", handlers.Repo.PusherAuth)
@@ -51,6 +60,7 @@ func routes() http.Handler {
 		mux.Get("/all-warning", handlers.Repo.AllWarningServices)
 		mux.Get("/all-problems", handlers.Repo.AllProblemServices)
 		mux.Get("/all-pending", handlers.Repo.AllPendingServices)
+		mux.Get("/all-unknown", handlers.Repo.AllUnknownServices)
 
 		// users
 		mux.Get("/users", handlers.Repo.AllUsers)
@@ -58,6 +68,12 @@ func routes() http.Handler {
 		mux.Post("/user/{id}", handlers.Repo.PostOneUser)
 		mux.Get("/user/delete/{id}", handlers.Repo.DeleteUser)
 
+		// webhooks
+		mux.Get("/webhooks", handlers.Repo.AllWebhooks)
+		mux.Get("/webhook/{id}", handlers.Repo.OneWebhook)
+		mux.Post("/webhook/{id}", handlers.Repo.PostOneWebhook)
+		mux.Get("/webhook/delete/{id}", handlers.Repo.DeleteWebhook)
+
 		// schedule
 		mux.Get("/schedule", handlers.Repo.ListEntries)
 
@@ -70,7 +86,9 @@ func routes() http.Handler {
 		mux.Get("/host/{id}", handlers.Repo.Host)
 		mux.Post("/host/{id}", handlers.Repo.PostHost)
 		mux.Post("/host/ajax/toggle-service", handlers.Repo.ToggleServiceForHost)
+		mux.Post("/host/ajax/service-settings", handlers.Repo.UpdateServiceSettings)
 		mux.Get("/perform-check/{id}/{oldStatus}", handlers.Repo.TestCheck)
+		mux.Get("/host-service/{id}/results", handlers.Repo.CheckResults)
 	})
 
 	// static files
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"time"
+	"vigilate/internal/broadcast"
 	"vigilate/internal/channeldata"
+	"vigilate/internal/checkers"
 	"vigilate/internal/config"
 	"vigilate/internal/driver"
 	"vigilate/internal/helpers"
@@ -21,11 +28,12 @@ import (
 )
 
 //setupApp initializes the Vigilate application configuration, including:
-// - Reading CLI flags for server, database, and Pusher settings
+// - Reading CLI flags for server, database, and websocket settings
 // - Connecting to the PostgreSQL database
 // - Setitng up session management with scs and Postgres store
 // - Initializing the mail job queue and worker dispatcher
-// - Loading application preferences and setting up Pusher WebSocket client
+// - Loading application preferences and setting up the websocket broadcaster
+//   (the built-in hub, or Pusher)
 // - Preparing global application and repository instances
 //
 
@@ -41,15 +49,34 @@ func setupApp() (*string, error) {
 	dbPass := flag.String("dbpass", "", "database password")
 	databaseName := flag.String("db", "vigilate", "database name")
 	dbSsl := flag.String("dbssl", "disable", "database ssl setting")
+	wsBackend := flag.String("ws", "hub", "websocket backend: hub (built in) or pusher")
 	pusherHost := flag.String("pusherHost", "", "pusher host")
 	pusherPort := flag.String("pusherPort", "443", "pusher port")
 	pusherApp := flag.String("pusherApp", "9", "pusher app id")
 	pusherKey := flag.String("pusherKey", "", "pusher key")
 	pusherSecret := flag.String("pusherSecret", "", "pusher secret")
 	pusherSecure := flag.Bool("pusherSecure", false, "pusher server uses SSL (true or false)")
+	pluginDir := flag.String("plugins", "", "directory of Nagios compatible check plugins (empty disables command checks)")
+	twilioURL := flag.String("twilioURL", "", "Twilio compatible SMS API base URL, https only (empty uses https://api.twilio.com)")
+	secretKey := flag.String("secretKey", os.Getenv("VIGILATE_SECRET_KEY"), "passphrase the passwords in check settings are encrypted with (default $VIGILATE_SECRET_KEY)")
 
 	flag.Parse()
 
+	//Command checks may only run plugins from this directory
+	checkers.PluginDir = *pluginDir
+
+	//Passwords in check settings, such as database and mail logins, are encrypted with this key
+	checkers.SecretKey = *secretKey
+
+	//The Twilio auth token is sent to this URL, so only an operator may set it
+	if *twilioURL != "" {
+		u, err := url.Parse(*twilioURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			fmt.Println("-twilioURL must be an https URL")
+			os.Exit(1)
+		}
+	}
+
 	//Ensure required flags are provided
 	if *dbUser == "" || *dbHost == "" || *dbPort == "" || *databaseName == "" || *identifier == "" {
 		fmt.Println("Missing required flags.")
@@ -116,6 +143,14 @@ func setupApp() (*string, error) {
 
 	app = a
 
+	// Parse email templates used by the mail workers
+	log.Println("Loading mail templates....")
+	templateCache, err := createMailTemplateCache()
+	if err != nil {
+		log.Fatal("Cannot load mail templates:", err)
+	}
+	app.TemplateCache = templateCache
+
 	// Initialize repository and handlers
 	repo = handlers.NewPostgresqlHandlers(db, &app)
 	handlers.NewHandlers(repo, &app)
@@ -132,30 +167,52 @@ func setupApp() (*string, error) {
 		preferenceMap[pref.Name] = string(pref.Preference)
 	}
 
-	// Add Pusher and app metadata to preference map
+	switch *wsBackend {
+	case "pusher":
+		// Create Pusher WebSocket client for real-time events
+		wsClient = broadcast.NewPusher(&pusher.Client{
+			AppID:  *pusherApp,
+			Secret: *pusherSecret,
+			Key:    *pusherKey,
+			Secure: *pusherSecure,
+			Host:   fmt.Sprintf("%s:%s", *pusherHost, *pusherPort),
+		})
+
+		//Log websocket host and port and if connection is secure {HTTPS}
+		log.Println("Host", fmt.Sprintf("%s:%s", *pusherHost, *pusherPort))
+		log.Println("Secure", *pusherSecure)
+	case "hub":
+		// The hub serves websockets itself; the key only has to match the browser's
+		if *pusherKey == "" {
+			*pusherKey = *identifier
+		}
+		// A random secret is fine, subscriptions are signed and checked by this process
+		if *pusherSecret == "" {
+			*pusherSecret = randomSecret()
+		}
+		wsHub = broadcast.NewHub(*pusherKey, *pusherSecret)
+		wsClient = wsHub
+
+		log.Println("Using built-in websocket hub")
+	default:
+		log.Fatal("Unknown websocket backend: ", *wsBackend)
+	}
+
+	// Add websocket and app metadata to preference map
+	preferenceMap["ws-backend"] = *wsBackend
 	preferenceMap["pusher-host"] = *pusherHost
 	preferenceMap["pusher-port"] = *pusherPort
 	preferenceMap["pusher-key"] = *pusherKey
+	preferenceMap["pusher-secure"] = fmt.Sprintf("%t", *pusherSecure)
+	preferenceMap["twilio-api-url"] = *twilioURL
 	preferenceMap["identifier"] = *identifier
 	preferenceMap["version"] = vigilateVersion
 
 	app.PreferenceMap = preferenceMap
 
-	// Create Pusher WebSocket client for real-time events
-	wsClient = pusher.Client{
-		AppID:  *pusherApp,
-		Secret: *pusherSecret,
-		Key:    *pusherKey,
-		Secure: *pusherSecure,
-		Host:   fmt.Sprintf("%s:%s", *pusherHost, *pusherPort),
-	}
-
-	//Log websocket host and port and if connection is secure {HTTPS}
-	log.Println("Host", fmt.Sprintf("%s:%s", *pusherHost, *pusherPort))
-	log.Println("Secure", *pusherSecure)
-
 	//Store websocket client for broadcasting events
 	app.WsClient = wsClient
+	app.PusherSecret = *pusherSecret
 
 	//Map to track scheduled jobs (serviceID > jobID)
 	monitorMap := make(map[int]cron.EntryID)
@@ -193,6 +250,28 @@ func setupApp() (*string, error) {
 	return insecurePort, err
 }
 
+// createMailTemplateCache parses every *.mail.tmpl file in ./email-templates,
+// keyed by file name
+func createMailTemplateCache() (map[string]*template.Template, error) {
+	cache := make(map[string]*template.Template)
+
+	pages, err := filepath.Glob("./email-templates/*.mail.tmpl")
+	if err != nil {
+		return cache, err
+	}
+
+	for _, page := range pages {
+		name := filepath.Base(page)
+		t, err := template.New(name).ParseFiles(page)
+		if err != nil {
+			return cache, err
+		}
+		cache[name] = t
+	}
+
+	return cache, nil
+}
+
 // createDirIfNotExist creates a directory if it does not exist
 func createDirIfNotExist(path string) error {
 	const mode = 0755
@@ -205,3 +284,12 @@ func createDirIfNotExist(path string) error {
 	}
 	return nil
 }
+
+// randomSecret returns a random hex string for signing websocket channel subscriptions
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal("Cannot generate websocket secret:", err)
+	}
+	return hex.EncodeToString(b)
+}
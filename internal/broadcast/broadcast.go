@@ -0,0 +1,61 @@
+package broadcast
+
+import (
+	"github.com/pusher/pusher-http-go"
+)
+
+//Package broadcast sends real time events to the browser. The Broadcaster
+//interface is implemented by the built-in WebSocket Hub, which speaks the
+//Pusher wire protocol so the pusher-js client works against it unchanged,
+//and by Pusher, which hands events to Pusher or a compatible server like ipê
+
+// Channel name prefixes with special meaning, as in Pusher
+const (
+	PrivatePrefix  = "private-"
+	PresencePrefix = "presence-"
+)
+
+// MemberData identifies a user subscribed to a presence channel
+type MemberData struct {
+	UserID   string            `json:"user_id"`
+	UserInfo map[string]string `json:"user_info,omitempty"`
+}
+
+// Broadcaster publishes events to channels and signs subscriptions to
+// private and presence channels
+type Broadcaster interface {
+	// Trigger sends an event with data to everyone subscribed to channel
+	Trigger(channel, event string, data interface{}) error
+	// AuthenticatePrivateChannel answers a client auth request for a private channel
+	AuthenticatePrivateChannel(params []byte) ([]byte, error)
+	// AuthenticatePresenceChannel answers a client auth request for a presence channel
+	AuthenticatePresenceChannel(params []byte, member MemberData) ([]byte, error)
+}
+
+// Pusher is a Broadcaster backed by the Pusher HTTP API
+type Pusher struct {
+	Client *pusher.Client
+}
+
+// NewPusher returns a Broadcaster that sends events through client
+func NewPusher(client *pusher.Client) *Pusher {
+	return &Pusher{Client: client}
+}
+
+// Trigger sends an event through Pusher
+func (p *Pusher) Trigger(channel, event string, data interface{}) error {
+	return p.Client.Trigger(channel, event, data)
+}
+
+// AuthenticatePrivateChannel signs a private channel subscription with the Pusher secret
+func (p *Pusher) AuthenticatePrivateChannel(params []byte) ([]byte, error) {
+	return p.Client.AuthenticatePrivateChannel(params)
+}
+
+// AuthenticatePresenceChannel signs a presence channel subscription with the Pusher secret
+func (p *Pusher) AuthenticatePresenceChannel(params []byte, member MemberData) ([]byte, error) {
+	return p.Client.AuthenticatePresenceChannel(params, pusher.MemberData{
+		UserID:   member.UserID,
+		UserInfo: member.UserInfo,
+	})
+}
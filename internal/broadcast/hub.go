@@ -0,0 +1,461 @@
+package broadcast
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// activityTimeout is how long, in seconds, a connection may stay silent before it is pinged
+const activityTimeout = 120
+
+// writeWait is the time allowed to write a message to a client
+const writeWait = 10 * time.Second
+
+// sendBuffer is the number of messages queued per client before it is dropped as too slow
+const sendBuffer = 64
+
+// message is a frame of the Pusher protocol; Data is a JSON encoded string
+// for events the server sends, and an object for events the client sends
+type message struct {
+	Event   string          `json:"event"`
+	Channel string          `json:"channel,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// subscribeData is the body of a pusher:subscribe request
+type subscribeData struct {
+	Channel     string `json:"channel"`
+	Auth        string `json:"auth"`
+	ChannelData string `json:"channel_data"`
+}
+
+// Hub is an in-process Broadcaster that serves WebSocket clients directly
+// It implements the parts of the Pusher protocol used by pusher-js: public,
+// private and presence channels, subscription auth and ping/pong
+type Hub struct {
+	Key    string
+	Secret string
+
+	mu       sync.RWMutex
+	channels map[string]map[*client]struct{}
+	members  map[string]map[string]*presenceMember
+
+	nextID uint64
+}
+
+// presenceMember tracks a user on a presence channel, who may have several connections open
+type presenceMember struct {
+	Info        map[string]string
+	Connections int
+}
+
+// client is a single WebSocket connection
+type client struct {
+	hub      *Hub
+	conn     *websocket.Conn
+	socketID string
+	send     chan []byte
+	done     chan struct{}
+	once     sync.Once
+
+	// members maps presence channels to the user id this connection joined as
+	members map[string]string
+}
+
+// NewHub returns a hub that signs channel subscriptions with key and secret
+func NewHub(key, secret string) *Hub {
+	return &Hub{
+		Key:      key,
+		Secret:   secret,
+		channels: make(map[string]map[*client]struct{}),
+		members:  make(map[string]map[string]*presenceMember),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and serves the client until it disconnects
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(h.serve).ServeHTTP(w, r)
+}
+
+// Trigger sends an event to every client subscribed to channel
+func (h *Hub) Trigger(channel, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	frame, err := encode(event, channel, string(payload))
+	if err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.channels[channel] {
+		c.queue(frame)
+	}
+
+	return nil
+}
+
+// AuthenticatePrivateChannel signs a private channel subscription
+func (h *Hub) AuthenticatePrivateChannel(params []byte) ([]byte, error) {
+	return h.authenticate(params, nil)
+}
+
+// AuthenticatePresenceChannel signs a presence channel subscription for member
+func (h *Hub) AuthenticatePresenceChannel(params []byte, member MemberData) ([]byte, error) {
+	return h.authenticate(params, &member)
+}
+
+// authenticate answers a pusher-js auth request, a form with socket_id and channel_name
+func (h *Hub) authenticate(params []byte, member *MemberData) ([]byte, error) {
+	v, err := url.ParseQuery(string(params))
+	if err != nil {
+		return nil, err
+	}
+
+	socketID, channel := v.Get("socket_id"), v.Get("channel_name")
+	if socketID == "" || channel == "" {
+		return nil, errors.New("broadcast: socket_id and channel_name are required")
+	}
+
+	response := make(map[string]string)
+	channelData := ""
+	if member != nil {
+		b, err := json.Marshal(member)
+		if err != nil {
+			return nil, err
+		}
+		channelData = string(b)
+		response["channel_data"] = channelData
+	}
+
+	response["auth"] = h.Key + ":" + h.sign(socketID, channel, channelData)
+
+	return json.Marshal(response)
+}
+
+// sign returns the HMAC-SHA256 signature Pusher uses for channel subscriptions
+func (h *Hub) sign(socketID, channel, channelData string) string {
+	toSign := socketID + ":" + channel
+	if channelData != "" {
+		toSign += ":" + channelData
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write([]byte(toSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validAuth reports whether auth is a signature this hub made for the subscription
+func (h *Hub) validAuth(socketID string, sub subscribeData) bool {
+	expected := h.Key + ":" + h.sign(socketID, sub.Channel, sub.ChannelData)
+	return hmac.Equal([]byte(expected), []byte(sub.Auth))
+}
+
+// serve runs the read loop for a connection
+func (h *Hub) serve(conn *websocket.Conn) {
+	//pusher-js connects to /app/{key}; like Pusher, refuse keys that are not ours
+	if key := path.Base(conn.Request().URL.Path); key != h.Key {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		_ = websocket.Message.Send(conn, string(errorFrame(4001, fmt.Sprintf("App key %s does not exist", key))))
+		_ = conn.Close()
+		return
+	}
+
+	c := &client{
+		hub:      h,
+		conn:     conn,
+		socketID: h.newSocketID(),
+		send:     make(chan []byte, sendBuffer),
+		done:     make(chan struct{}),
+		members:  make(map[string]string),
+	}
+	defer h.disconnect(c)
+
+	go c.writeLoop()
+
+	established, _ := json.Marshal(map[string]interface{}{
+		"socket_id":        c.socketID,
+		"activity_timeout": activityTimeout,
+	})
+	frame, _ := encode("pusher:connection_established", "", string(established))
+	c.queue(frame)
+
+	for {
+		//The write loop pings idle clients, so a silent connection is a dead one
+		_ = conn.SetReadDeadline(time.Now().Add(2 * activityTimeout * time.Second))
+
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			return
+		}
+
+		var m message
+		if err := json.Unmarshal(raw, &m); err != nil {
+			c.error(4000, "invalid message")
+			continue
+		}
+
+		switch m.Event {
+		case "pusher:ping":
+			frame, _ := encode("pusher:pong", "", "{}")
+			c.queue(frame)
+		case "pusher:pong":
+		case "pusher:subscribe":
+			var sub subscribeData
+			if err := json.Unmarshal(m.Data, &sub); err != nil {
+				c.error(4000, "invalid subscribe request")
+				continue
+			}
+			h.subscribe(c, sub)
+		case "pusher:unsubscribe":
+			var sub subscribeData
+			if err := json.Unmarshal(m.Data, &sub); err != nil {
+				continue
+			}
+			h.unsubscribe(c, sub.Channel)
+		default:
+			//Client events are not supported
+		}
+	}
+}
+
+// subscribe adds a client to a channel, checking the signature for private and presence channels
+func (h *Hub) subscribe(c *client, sub subscribeData) {
+	isPrivate := strings.HasPrefix(sub.Channel, PrivatePrefix)
+	isPresence := strings.HasPrefix(sub.Channel, PresencePrefix)
+
+	if sub.Channel == "" {
+		c.error(4000, "channel is required")
+		return
+	}
+
+	if (isPrivate || isPresence) && !h.validAuth(c.socketID, sub) {
+		c.error(4009, fmt.Sprintf("invalid signature for channel %s", sub.Channel))
+		return
+	}
+
+	var member MemberData
+	if isPresence {
+		if err := json.Unmarshal([]byte(sub.ChannelData), &member); err != nil || member.UserID == "" {
+			c.error(4000, "presence channels need channel_data with a user_id")
+			return
+		}
+	}
+
+	h.mu.Lock()
+	if h.channels[sub.Channel] == nil {
+		h.channels[sub.Channel] = make(map[*client]struct{})
+	}
+	if _, ok := h.channels[sub.Channel][c]; ok {
+		h.mu.Unlock()
+		return
+	}
+	h.channels[sub.Channel][c] = struct{}{}
+
+	succeeded := "{}"
+	var added []byte
+	if isPresence {
+		if h.members[sub.Channel] == nil {
+			h.members[sub.Channel] = make(map[string]*presenceMember)
+		}
+		m, ok := h.members[sub.Channel][member.UserID]
+		if !ok {
+			m = &presenceMember{Info: member.UserInfo}
+			h.members[sub.Channel][member.UserID] = m
+			added, _ = json.Marshal(member)
+		}
+		m.Connections++
+		c.members[sub.Channel] = member.UserID
+
+		succeeded = h.presenceState(sub.Channel)
+	}
+
+	//Tell everyone else that a new user joined
+	if added != nil {
+		frame, _ := encode("pusher_internal:member_added", sub.Channel, string(added))
+		for other := range h.channels[sub.Channel] {
+			if other != c {
+				other.queue(frame)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	frame, _ := encode("pusher_internal:subscription_succeeded", sub.Channel, succeeded)
+	c.queue(frame)
+}
+
+// presenceState returns the member list sent when joining a presence channel
+// The caller must hold the lock
+func (h *Hub) presenceState(channel string) string {
+	ids := make([]string, 0, len(h.members[channel]))
+	hash := make(map[string]map[string]string)
+	for id, m := range h.members[channel] {
+		ids = append(ids, id)
+		hash[id] = m.Info
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{
+		"presence": map[string]interface{}{
+			"ids":   ids,
+			"hash":  hash,
+			"count": len(ids),
+		},
+	})
+	return string(b)
+}
+
+// unsubscribe removes a client from a channel
+func (h *Hub) unsubscribe(c *client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c, channel)
+}
+
+// leave removes a client from a channel, announcing presence members who are gone
+// The caller must hold the lock
+func (h *Hub) leave(c *client, channel string) {
+	subscribers, ok := h.channels[channel]
+	if !ok {
+		return
+	}
+	delete(subscribers, c)
+	if len(subscribers) == 0 {
+		delete(h.channels, channel)
+	}
+
+	userID, ok := c.members[channel]
+	if !ok {
+		return
+	}
+	delete(c.members, channel)
+
+	m := h.members[channel][userID]
+	if m == nil {
+		return
+	}
+	m.Connections--
+	if m.Connections > 0 {
+		return
+	}
+
+	delete(h.members[channel], userID)
+	if len(h.members[channel]) == 0 {
+		delete(h.members, channel)
+	}
+
+	removed, _ := json.Marshal(MemberData{UserID: userID})
+	frame, _ := encode("pusher_internal:member_removed", channel, string(removed))
+	for other := range subscribers {
+		other.queue(frame)
+	}
+}
+
+// disconnect removes a client from every channel and closes its connection
+func (h *Hub) disconnect(c *client) {
+	h.mu.Lock()
+	for channel, subscribers := range h.channels {
+		if _, ok := subscribers[c]; ok {
+			h.leave(c, channel)
+		}
+	}
+	h.mu.Unlock()
+
+	c.close()
+}
+
+// newSocketID returns a unique id in the Pusher format, two numbers separated by a dot
+func (h *Hub) newSocketID() string {
+	n := atomic.AddUint64(&h.nextID, 1)
+	r, err := rand.Int(rand.Reader, big.NewInt(1_000_000_000))
+	if err != nil {
+		r = big.NewInt(time.Now().UnixNano() % 1_000_000_000)
+	}
+	return fmt.Sprintf("%d.%d", n, r.Int64())
+}
+
+// queue sends a frame to the client without blocking; a client that cannot
+// keep up is disconnected rather than holding up everyone else
+func (c *client) queue(frame []byte) {
+	select {
+	case <-c.done:
+	case c.send <- frame:
+	default:
+		log.Println("broadcast: dropping slow client", c.socketID)
+		c.close()
+	}
+}
+
+// error sends a pusher:error to the client
+func (c *client) error(code int, msg string) {
+	c.queue(errorFrame(code, msg))
+}
+
+// errorFrame builds a pusher:error frame
+func errorFrame(code int, msg string) []byte {
+	b, _ := json.Marshal(message{
+		Event: "pusher:error",
+		Data:  json.RawMessage(fmt.Sprintf(`{"code":%d,"message":%q}`, code, msg)),
+	})
+	return b
+}
+
+// writeLoop writes queued frames, pinging the client when nothing has been sent for a while
+func (c *client) writeLoop() {
+	ping, _ := encode("pusher:ping", "", "{}")
+	ticker := time.NewTicker(activityTimeout * time.Second)
+	defer ticker.Stop()
+
+	for {
+		var frame []byte
+		select {
+		case <-c.done:
+			return
+		case frame = <-c.send:
+		case <-ticker.C:
+			frame = ping
+		}
+
+		_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := websocket.Message.Send(c.conn, string(frame)); err != nil {
+			c.close()
+			return
+		}
+	}
+}
+
+// close shuts the connection down once
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// encode builds a server frame; data is sent as a JSON encoded string
+func encode(event, channel, data string) ([]byte, error) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(message{Event: event, Channel: channel, Data: d})
+}
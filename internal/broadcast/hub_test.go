@@ -0,0 +1,71 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+// dial connects to the hub under the app key and returns the first frame it sends
+func dial(t *testing.T, srv *httptest.Server, key string) message {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/app/" + key + "?protocol=7"
+	ws, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var raw []byte
+	if err := websocket.Message.Receive(ws, &raw); err != nil {
+		t.Fatal(err)
+	}
+	var m message
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHubAppKey(t *testing.T) {
+	srv := httptest.NewServer(NewHub("key", "secret"))
+	defer srv.Close()
+
+	if m := dial(t, srv, "key"); m.Event != "pusher:connection_established" {
+		t.Errorf("right key: got %s, want pusher:connection_established", m.Event)
+	}
+
+	m := dial(t, srv, "other")
+	if m.Event != "pusher:error" {
+		t.Fatalf("wrong key: got %s, want pusher:error", m.Event)
+	}
+	var data struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(m.Data, &data); err != nil || data.Code != 4001 {
+		t.Errorf("wrong key: got %s, want code 4001", m.Data)
+	}
+}
+
+func TestHubSign(t *testing.T) {
+	h := NewHub("key", "secret")
+	body, err := h.AuthenticatePrivateChannel([]byte("socket_id=1.2&channel_name=private-channel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := subscribeData{Channel: "private-channel", Auth: resp["auth"]}
+	if !h.validAuth("1.2", sub) {
+		t.Error("signature not accepted for the socket it was made for")
+	}
+	if h.validAuth("1.3", sub) {
+		t.Error("signature accepted for another socket")
+	}
+}
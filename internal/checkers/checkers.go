@@ -0,0 +1,231 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"vigilate/internal/models"
+)
+
+//Package checkers defines the Checker interface and the registry that maps
+//service types to the code that monitors them. Each service type registers
+//itself by name (matching services.service_name) and decodes its own
+//per-host-service settings
+
+// Status values a check can produce
+const (
+	StatusPending = "pending"
+	StatusHealthy = "healthy"
+	StatusWarning = "warning"
+	StatusProblem = "problem"
+	// StatusUnknown is reported when a check cannot tell how the service is,
+	// e.g. a plugin exiting with code 3
+	StatusUnknown = "unknown"
+)
+
+// defaultTimeout bounds a single check whose settings have no timeout
+const defaultTimeout = 30 * time.Second
+
+// timeoutMargin is added to the time a check's settings allow, so that the
+// checker's own timeout fires first and reports what timed out
+const timeoutMargin = 5 * time.Second
+
+// Result holds the outcome of a single check
+type Result struct {
+	Status  string
+	Message string
+	// Latency is how long the check took; Run fills it in when the checker does not
+	Latency time.Duration
+	// Err is the underlying error, if the check failed because of one
+	Err error
+	// CertExpiry is set by checkers that inspect a TLS certificate
+	CertExpiry time.Time
+	// Metrics are named measurements taken by the check, stored with the result
+	Metrics map[string]float64
+}
+
+// Target is the host service a checker runs against
+type Target struct {
+	Host        models.Host
+	HostService models.HostService
+	// Family is ipv4 or ipv6 when the check must use that address family; Run sets it
+	Family string
+}
+
+// Settings decodes the host service settings into v
+// v should already hold the checker defaults so that missing keys keep them
+func (t Target) Settings(v interface{}) error {
+	raw := strings.TrimSpace(t.HostService.Settings)
+	if raw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), v)
+}
+
+// Checker is implemented by every service type that can be monitored
+type Checker interface {
+	// DefaultSettings returns a pointer to the settings used when a host service sets none
+	DefaultSettings() interface{}
+	// Check runs the check against the target and reports its result
+	Check(ctx context.Context, t Target) Result
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Checker)
+)
+
+// normalize makes registry lookups case and whitespace insensitive
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Register makes a checker available for the service with the given name
+// It panics if a checker is registered twice for the same name
+func Register(name string, c Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := normalize(name)
+	if _, exists := registry[key]; exists {
+		panic(fmt.Sprintf("checkers: Register called twice for %q", name))
+	}
+	registry[key] = c
+}
+
+// Lookup returns the checker registered for a service name
+func Lookup(name string) (Checker, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c, ok := registry[normalize(name)]
+	return c, ok
+}
+
+// Names returns the (normalized) names of all registered checkers, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names []string
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// longSettings is implemented by settings that allow a check to run longer
+// than their timeout, such as several pings or transaction steps
+type longSettings interface {
+	// duration returns the longest a check with these settings may take
+	duration() time.Duration
+}
+
+// checkTimeout returns how long a check may run: what its settings allow,
+// from duration or the timeout in seconds, plus timeoutMargin
+// Checks whose settings have no timeout get defaultTimeout
+func checkTimeout(c Checker, t Target) time.Duration {
+	s := c.DefaultSettings()
+	if err := t.Settings(s); err != nil {
+		//The check reports the invalid settings straight away
+		return defaultTimeout
+	}
+	if l, ok := s.(longSettings); ok {
+		return l.duration() + timeoutMargin
+	}
+
+	var timeout struct {
+		Timeout int `json:"timeout"`
+	}
+	b, err := json.Marshal(s)
+	if err != nil || json.Unmarshal(b, &timeout) != nil || timeout.Timeout < 1 {
+		return defaultTimeout
+	}
+	return time.Duration(timeout.Timeout)*time.Second + timeoutMargin
+}
+
+// Run looks up the checker for the target's service and runs it over the host
+// service's address family, then applies the response time thresholds
+// Services without a checker stay pending with a message saying so
+func Run(t Target) Result {
+	c, ok := Lookup(t.HostService.Service.ServiceName)
+	if !ok {
+		return Result{
+			Status:  StatusPending,
+			Message: fmt.Sprintf("no checker registered for service %q", t.HostService.Service.ServiceName),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(c, t))
+	defer cancel()
+
+	switch t.HostService.AddressFamily {
+	case FamilyIPv4, FamilyIPv6:
+		t.Family = t.HostService.AddressFamily
+	case FamilyBoth:
+		if _, ok := c.(familyIndependent); !ok {
+			return checkFamilies(ctx, c, t)
+		}
+	}
+
+	start := time.Now()
+	result := c.Check(ctx, t)
+	if result.Latency == 0 {
+		result.Latency = time.Since(start)
+	}
+	return applyThresholds(t.HostService, result)
+}
+
+// DefaultSettingsJSON returns the default settings of a service as indented JSON
+func DefaultSettingsJSON(name string) string {
+	c, ok := Lookup(name)
+	if !ok {
+		return "{}"
+	}
+	out, err := json.MarshalIndent(c.DefaultSettings(), "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
+
+// ValidateSettings checks that raw decodes into the settings of the named service
+// Unknown keys are rejected so that typos do not silently fall back to defaults
+func ValidateSettings(name, raw string) error {
+	c, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("no checker registered for service %q", name)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	settings := c.DefaultSettings()
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(settings); err != nil {
+		return fmt.Errorf("invalid settings for %s: %w", name, err)
+	}
+
+	//Settings that can check themselves further do so
+	if v, ok := settings.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("invalid settings for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// roundLatency rounds a latency for display, keeping sub-millisecond times readable
+func roundLatency(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,97 @@
+package checkers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+// target returns the target for a service on a host with the given settings
+func target(service string, h models.Host, settings string) Target {
+	return Target{
+		Host: h,
+		HostService: models.HostService{
+			Settings: settings,
+			Service:  models.Services{ServiceName: service},
+		},
+	}
+}
+
+// wantStatus fails the test when a result does not have the expected status
+func wantStatus(t *testing.T, r Result, status string) {
+	t.Helper()
+	if r.Status != status {
+		t.Errorf("got %s (%s), want %s", r.Status, r.Message, status)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"HTTP", "http", " Http "} {
+		if _, ok := Lookup(name); !ok {
+			t.Errorf("Lookup(%q) found no checker", name)
+		}
+	}
+	if _, ok := Lookup("no such service"); ok {
+		t.Error("Lookup found a checker for an unknown service")
+	}
+}
+
+func TestRunUnknownService(t *testing.T) {
+	r := Run(target("no such service", models.Host{}, ""))
+	wantStatus(t, r, StatusPending)
+}
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		settings string
+		wantErr  string
+	}{
+		{"empty", ServiceHTTP, "", ""},
+		{"defaults", ServiceHTTP, `{"timeout": 5}`, ""},
+		{"unknown key", ServiceHTTP, `{"timeuot": 5}`, "unknown field"},
+		{"not json", ServiceHTTP, `{`, "invalid settings"},
+		{"validated", ServiceTCP, `{"port": 0}`, "port must be"},
+		{"unknown service", "no such service", `{}`, "no checker registered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings(tt.service, tt.settings)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("got %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		settings string
+		want     time.Duration
+	}{
+		{"default timeout", ServiceTCP, "", 10*time.Second + timeoutMargin},
+		{"above the old cap", ServiceCommand, `{"command": "check_x", "timeout": 120}`, 120*time.Second + timeoutMargin},
+		{"ping count", ServicePing, `{"count": 10, "interval_ms": 1000, "timeout": 2}`, 60*time.Second + timeoutMargin},
+		{"transaction steps", ServiceTransaction, `{"steps": [{"url": "http://a", "timeout": 40}, {"url": "http://b"}]}`, 50*time.Second + timeoutMargin},
+		{"no timeout setting", ServiceHeartbeat, "", defaultTimeout},
+		{"invalid settings", ServiceTCP, `{`, defaultTimeout},
+	}
+
+	for _, tt := range tests {
+		c, ok := Lookup(tt.service)
+		if !ok {
+			t.Fatalf("%s: no checker", tt.service)
+		}
+		if got := checkTimeout(c, target(tt.service, models.Host{}, tt.settings)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceCommand is the name of the Nagios plugin command service in the services table
+const ServiceCommand = "Command"
+
+// maxPluginOutput is the most plugin output kept; Nagios keeps less
+const maxPluginOutput = 64 << 10
+
+// PluginDir is the directory command checks may run executables from, like
+// Nagios' $USER1$; command checks are refused while it is empty
+var PluginDir string
+
+func init() {
+	Register(ServiceCommand, commandChecker{})
+}
+
+// commandSettings are the per-host-service settings for command checks
+type commandSettings struct {
+	// Command is the plugin to run, as a name or path inside PluginDir
+	Command string `json:"command"`
+	// Args are passed to the plugin after macro expansion: $HOSTADDRESS$,
+	// $HOSTNAME$ and $USER1$ (the plugin directory)
+	Args []string `json:"args"`
+	// Timeout in seconds; a plugin still running is killed and reported as a problem
+	Timeout int `json:"timeout"`
+}
+
+// commandChecker runs a Nagios compatible plugin and maps its exit code to a status
+type commandChecker struct{}
+
+// DefaultSettings returns the command check defaults
+func (commandChecker) DefaultSettings() interface{} {
+	return &commandSettings{Args: []string{"-H", "$HOSTADDRESS$"}, Timeout: 20}
+}
+
+// validate reports settings that can never work
+func (s commandSettings) validate() error {
+	if s.Command == "" {
+		return errors.New("no command configured")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	return nil
+}
+
+// Check runs the plugin and reports its status, output and performance data
+func (c commandChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*commandSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	path, err := pluginPath(s.Command)
+	if err != nil {
+		return Result{Status: StatusUnknown, Message: err.Error(), Err: err}
+	}
+
+	args := make([]string, len(s.Args))
+	for i, a := range s.Args {
+		args[i] = expandMacros(a, t)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	var out limitedBuffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = PluginDir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	//Do not wait forever on children that keep the output open after a kill
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	latency := time.Since(start)
+
+	name := filepath.Base(path)
+	if ctx.Err() == context.DeadlineExceeded {
+		//Report the time it ran, as the check's own deadline may have come first
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s timed out after %s", name, roundLatency(latency)), Latency: latency, Err: ctx.Err()}
+	}
+
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return Result{Status: StatusUnknown, Message: fmt.Sprintf("cannot run %s: %s", name, err), Latency: latency, Err: err}
+		}
+		code = exitErr.ExitCode()
+	}
+
+	text, perfdata := parsePluginOutput(out.String())
+	if text == "" {
+		text = "(no output)"
+	}
+
+	result := Result{Status: pluginStatus(code), Message: text, Latency: latency, Metrics: parsePerfdata(perfdata)}
+	if code < 0 || code > 3 {
+		result.Message = fmt.Sprintf("%s (exit code %d is out of bounds)", text, code)
+	}
+	return result
+}
+
+// pluginPath resolves a command to an executable inside PluginDir, with
+// symlinks resolved
+func pluginPath(command string) (string, error) {
+	if PluginDir == "" {
+		return "", errors.New("command checks are disabled; start vigilate with -plugins set to the plugin directory")
+	}
+	if command == "" {
+		return "", errors.New("no command configured")
+	}
+
+	dir, err := filepath.Abs(PluginDir)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.ReplaceAll(command, "$USER1$", dir)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	//Refuse anything that resolves outside the plugin directory, through .. or symlinks
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s not found", command)
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(realDir, real); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("plugin %s is outside the plugin directory", command)
+	}
+
+	info, err := os.Stat(real)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("plugin %s is not executable", command)
+	}
+
+	//Run the resolved path, so a symlink swapped after the check cannot lead outside
+	return real, nil
+}
+
+// expandMacros replaces the host macros Nagios plugins are usually called with
+func expandMacros(arg string, t Target) string {
+	return strings.NewReplacer(
+		"$HOSTADDRESS$", hostAddress(t),
+		"$HOSTNAME$", t.Host.HostName,
+		"$USER1$", PluginDir,
+	).Replace(arg)
+}
+
+// pluginStatus maps a plugin exit code to a status; codes out of range are unknown
+func pluginStatus(code int) string {
+	switch code {
+	case 0:
+		return StatusHealthy
+	case 1:
+		return StatusWarning
+	case 2:
+		return StatusProblem
+	default:
+		return StatusUnknown
+	}
+}
+
+// parsePluginOutput splits plugin output into the first line of text and the
+// performance data, which follows a | on the first line and on any later line
+func parsePluginOutput(out string) (string, string) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	text, perf, _ := strings.Cut(lines[0], "|")
+	perfdata := []string{strings.TrimSpace(perf)}
+
+	//Long output may carry more performance data after its own |
+	for _, l := range lines[1:] {
+		if _, more, ok := strings.Cut(l, "|"); ok {
+			perfdata = append(perfdata, strings.TrimSpace(more))
+		}
+	}
+
+	return strings.TrimSpace(text), strings.TrimSpace(strings.Join(perfdata, " "))
+}
+
+// parsePerfdata parses performance data such as
+// 'time'=0.012s;1.000;2.000;0.000 size=512B;;;0
+// into metrics named by label, keeping the value and, when given, the warning
+// and critical thresholds as label_warn and label_crit
+func parsePerfdata(perfdata string) map[string]float64 {
+	metrics := make(map[string]float64)
+
+	for perfdata != "" {
+		perfdata = strings.TrimLeft(perfdata, " \t")
+		if perfdata == "" {
+			break
+		}
+
+		var label string
+		if perfdata[0] == '\'' {
+			//quoted labels may contain spaces; '' is an escaped quote
+			end := 1
+			for end < len(perfdata) {
+				if perfdata[end] == '\'' {
+					if end+1 < len(perfdata) && perfdata[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(perfdata) {
+				break
+			}
+			label = strings.ReplaceAll(perfdata[1:end], "''", "'")
+			perfdata = perfdata[end+1:]
+		} else {
+			eq := strings.IndexByte(perfdata, '=')
+			if eq < 0 {
+				break
+			}
+			label = perfdata[:eq]
+			perfdata = perfdata[eq:]
+		}
+
+		if !strings.HasPrefix(perfdata, "=") {
+			break
+		}
+		perfdata = perfdata[1:]
+
+		field := perfdata
+		if sp := strings.IndexAny(perfdata, " \t"); sp >= 0 {
+			field, perfdata = perfdata[:sp], perfdata[sp:]
+		} else {
+			perfdata = ""
+		}
+
+		name := metricName(label)
+		if name == "" {
+			continue
+		}
+		parts := strings.Split(field, ";")
+		if v, ok := perfValue(parts[0]); ok {
+			metrics[name] = v
+		}
+		if len(parts) > 1 {
+			if v, ok := perfValue(parts[1]); ok {
+				metrics[name+"_warn"] = v
+			}
+		}
+		if len(parts) > 2 {
+			if v, ok := perfValue(parts[2]); ok {
+				metrics[name+"_crit"] = v
+			}
+		}
+	}
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	return metrics
+}
+
+// perfValue parses a performance data number, dropping its unit of measure;
+// ranges such as 10:20 or ~:5 are not single numbers and are skipped
+func perfValue(s string) (float64, bool) {
+	s = strings.TrimRight(strings.TrimSpace(s), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ%")
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// metricName turns a performance data label into a metric name
+func metricName(label string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(label)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.', r == '-', r == '/':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// limitedBuffer keeps the first maxPluginOutput bytes written to it and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+// Write stores what fits and reports everything as written, so the plugin is not stopped by a short write
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxPluginOutput - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
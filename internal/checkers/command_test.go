@@ -0,0 +1,181 @@
+package checkers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestParsePerfdata(t *testing.T) {
+	tests := []struct {
+		name     string
+		perfdata string
+		want     map[string]float64
+	}{
+		{"empty", "", nil},
+		{"value only", "users=5", map[string]float64{"users": 5}},
+		{"thresholds", "time=0.012s;1.000;2.000;0.000", map[string]float64{"time": 0.012, "time_warn": 1, "time_crit": 2}},
+		{"empty thresholds", "size=512B;;;0", map[string]float64{"size": 512}},
+		{"percent", "pl=0%;20;60", map[string]float64{"pl": 0, "pl_warn": 20, "pl_crit": 60}},
+		{"ranges skipped", "load=1.5;10:20;~:30", map[string]float64{"load": 1.5}},
+		{"quoted label", "'free space'=20GB", map[string]float64{"free_space": 20}},
+		{"escaped quote", "'it''s'=1", map[string]float64{"it_s": 1}},
+		{"several", "a=1 B=2\tc/d=3", map[string]float64{"a": 1, "b": 2, "c/d": 3}},
+		{"unknown value", "a=U b=2", map[string]float64{"b": 2}},
+		{"garbage", "no perfdata here", nil},
+		{"unterminated quote", "'label=1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePerfdata(tt.perfdata); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		out      string
+		text     string
+		perfdata string
+	}{
+		{"OK", "OK", ""},
+		{"OK - up | time=1s\n", "OK - up", "time=1s"},
+		{"DISK OK\n/ 20% used\n/var 40% used | var=40%\n", "DISK OK", "var=40%"},
+		{"OK | a=1\nmore text | b=2", "OK", "a=1 b=2"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		text, perfdata := parsePluginOutput(tt.out)
+		if text != tt.text || perfdata != tt.perfdata {
+			t.Errorf("parsePluginOutput(%q) = %q, %q; want %q, %q", tt.out, text, perfdata, tt.text, tt.perfdata)
+		}
+	}
+}
+
+func TestPluginStatus(t *testing.T) {
+	for code, want := range map[int]string{0: StatusHealthy, 1: StatusWarning, 2: StatusProblem, 3: StatusUnknown, 4: StatusUnknown, -1: StatusUnknown} {
+		if got := pluginStatus(code); got != want {
+			t.Errorf("pluginStatus(%d) = %s, want %s", code, got, want)
+		}
+	}
+}
+
+// pluginDir sets PluginDir to a temporary directory holding a check_test
+// plugin, which prints its arguments and exits with the code in $2
+func pluginDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"TEST - $1 | time=0.5s;1;2\"\nexit $2\n"
+	if err := os.WriteFile(filepath.Join(dir, "check_test"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	old := PluginDir
+	PluginDir = dir
+	t.Cleanup(func() { PluginDir = old })
+	return dir
+}
+
+func TestCommandChecker(t *testing.T) {
+	pluginDir(t)
+	host := models.Host{HostName: "web1", IP: "192.0.2.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"ok", `{"command": "check_test", "args": ["$HOSTADDRESS$", "0"]}`, StatusHealthy, "TEST - 192.0.2.1"},
+		{"warning", `{"command": "check_test", "args": ["$HOSTNAME$", "1"]}`, StatusWarning, "TEST - web1"},
+		{"critical", `{"command": "$USER1$/check_test", "args": ["x", "2"]}`, StatusProblem, "TEST - x"},
+		{"out of bounds", `{"command": "check_test", "args": ["x", "7"]}`, StatusUnknown, "exit code 7 is out of bounds"},
+		{"not found", `{"command": "check_missing"}`, StatusUnknown, "not found"},
+		{"no command", `{}`, StatusProblem, "no command configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceCommand, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+
+	r := Run(target(ServiceCommand, host, `{"command": "check_test", "args": ["x", "0"]}`))
+	if r.Metrics["time"] != 0.5 || r.Metrics["time_crit"] != 2 {
+		t.Errorf("metrics %v, want time 0.5 and time_crit 2", r.Metrics)
+	}
+
+	if err := os.WriteFile(filepath.Join(PluginDir, "check_slow"), []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r = Run(target(ServiceCommand, host, `{"command": "check_slow", "timeout": 1}`))
+	wantStatus(t, r, StatusProblem)
+	if !strings.Contains(r.Message, "check_slow timed out after 1") || r.Latency < time.Second {
+		t.Errorf("got %q after %s, want a timeout after the time it ran", r.Message, r.Latency)
+	}
+}
+
+func TestPluginPath(t *testing.T) {
+	dir := pluginDir(t)
+
+	outside := filepath.Join(t.TempDir(), "check_outside")
+	if err := os.WriteFile(outside, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "check_link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("check_test", filepath.Join(dir, "check_alias")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("plugins\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	real, err := filepath.EvalSymlinks(filepath.Join(dir, "check_test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		want    string
+		err     string
+	}{
+		{"check_test", real, ""},
+		{"check_alias", real, ""},
+		{filepath.Join(dir, "check_test"), real, ""},
+		{"../check_test", "", "not found"},
+		{outside, "", "outside the plugin directory"},
+		{"check_link", "", "outside the plugin directory"},
+		{"README", "", "not executable"},
+		{".", "", "not executable"},
+	}
+	for _, tt := range tests {
+		got, err := pluginPath(tt.command)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("pluginPath(%q) error %v, want %q", tt.command, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("pluginPath(%q) = %q, %v; want %q", tt.command, got, err, tt.want)
+		}
+	}
+
+	PluginDir = ""
+	if _, err := pluginPath("check_test"); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("got %v with no plugin directory, want disabled", err)
+	}
+}
@@ -0,0 +1,132 @@
+package checkers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//Package checkers contains the parts shared by the database checks: each
+//connects with stored credentials, runs a probe query and reports how long it
+//took, optionally matching the value returned and grading replication lag
+
+// dbSettings are the settings every database check has
+type dbSettings struct {
+	// Port to connect to
+	Port int `json:"port"`
+	// User to log in as
+	User string `json:"user"`
+	// Password is stored encrypted; a plain value is encrypted when the settings are saved
+	Password string `json:"password"`
+	// Timeout is the connect and query timeout in seconds
+	Timeout int `json:"timeout"`
+	// Query is the probe run once connected; for Redis, a command such as PING
+	Query string `json:"query"`
+	// Expect is a regular expression the first value returned must match
+	Expect string `json:"expect"`
+	// LagWarning and LagProblem are replication lag thresholds in seconds;
+	// when either is set the server must be a replica and its lag is measured
+	LagWarning int `json:"lag_warning"`
+	LagProblem int `json:"lag_problem"`
+}
+
+// probe is what a database check learned from the server
+type probe struct {
+	// Value is the first value the query returned
+	Value string
+	// Rows is false when the query returned nothing
+	Rows bool
+	// Replica is set when the server replicates from another
+	Replica bool
+	// Lag is the replication lag in seconds, when Replica is set
+	Lag float64
+	// Broken explains why replication is not running, when it is not
+	Broken string
+}
+
+// validate reports settings that can never work
+func (s dbSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if s.Query == "" {
+		return errors.New("query must not be empty")
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	if s.LagWarning < 0 || s.LagProblem < 0 {
+		return errors.New("replication lag thresholds cannot be negative")
+	}
+	if s.LagWarning > 0 && s.LagProblem > 0 && s.LagProblem < s.LagWarning {
+		return errors.New("lag_problem must not be lower than lag_warning")
+	}
+	return nil
+}
+
+// checkLag reports whether replication lag is to be measured
+func (s dbSettings) checkLag() bool {
+	return s.LagWarning > 0 || s.LagProblem > 0
+}
+
+// result grades what the probe found
+func (s dbSettings) result(addr string, p probe, latency time.Duration) Result {
+	result := Result{
+		Status:  StatusHealthy,
+		Latency: latency,
+		Metrics: map[string]float64{"query_ms": float64(latency) / float64(time.Millisecond)},
+	}
+
+	returned := fmt.Sprintf("returned %s", truncate(p.Value, 100))
+	if !p.Rows {
+		returned = "returned no rows"
+	}
+	result.Message = fmt.Sprintf("%s - %s %s in %s", addr, s.Query, returned, roundLatency(latency))
+
+	if s.Expect != "" {
+		if re := regexp.MustCompile(s.Expect); !p.Rows || !re.MatchString(p.Value) {
+			result.Status = StatusProblem
+			result.Message = fmt.Sprintf("%s, expected a match for %s", result.Message, s.Expect)
+			return result
+		}
+	}
+
+	if !s.checkLag() {
+		return result
+	}
+
+	switch {
+	case !p.Replica:
+		result.Status = StatusWarning
+		result.Message += ", not a replica so replication lag was not measured"
+	case p.Broken != "":
+		result.Status = StatusProblem
+		result.Message = fmt.Sprintf("%s, replication is not running: %s", result.Message, p.Broken)
+	default:
+		result.Metrics["replication_lag_s"] = p.Lag
+		lag := time.Duration(p.Lag * float64(time.Second)).Round(time.Second)
+		switch {
+		case s.LagProblem > 0 && p.Lag > float64(s.LagProblem):
+			result.Status = StatusProblem
+			result.Message = fmt.Sprintf("%s, replication lag %s over %ds", result.Message, lag, s.LagProblem)
+		case s.LagWarning > 0 && p.Lag > float64(s.LagWarning):
+			result.Status = StatusWarning
+			result.Message = fmt.Sprintf("%s, replication lag %s over %ds", result.Message, lag, s.LagWarning)
+		default:
+			result.Message = fmt.Sprintf("%s, replication lag %s", result.Message, lag)
+		}
+	}
+	return result
+}
+
+// dbFailure reports a database check that could not complete
+// Drivers may put each failed connection attempt on its own line; the message keeps to one
+func dbFailure(addr, what string, latency time.Duration, err error) Result {
+	msg := strings.Join(strings.Fields(err.Error()), " ")
+	return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s: %s", addr, what, msg), Latency: latency, Err: err}
+}
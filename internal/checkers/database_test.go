@@ -0,0 +1,85 @@
+package checkers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestDBResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       dbSettings
+		p       probe
+		status  string
+		message string
+	}{
+		{"healthy", dbSettings{Query: "SELECT 1"}, probe{Value: "1", Rows: true}, StatusHealthy, "SELECT 1 returned 1"},
+		{"no rows", dbSettings{Query: "SELECT 1"}, probe{}, StatusHealthy, "returned no rows"},
+		{"expect matches", dbSettings{Query: "SELECT 1", Expect: "^1$"}, probe{Value: "1", Rows: true}, StatusHealthy, "returned 1"},
+		{"expect fails", dbSettings{Query: "SELECT 1", Expect: "^2$"}, probe{Value: "1", Rows: true}, StatusProblem, "expected a match for ^2$"},
+		{"expect without rows", dbSettings{Query: "SELECT 1", Expect: ".*"}, probe{}, StatusProblem, "expected a match"},
+		{"not a replica", dbSettings{Query: "SELECT 1", LagWarning: 10}, probe{Rows: true}, StatusWarning, "not a replica"},
+		{"replication broken", dbSettings{Query: "SELECT 1", LagProblem: 60}, probe{Rows: true, Replica: true, Broken: "io thread stopped"}, StatusProblem, "replication is not running: io thread stopped"},
+		{"lag ok", dbSettings{Query: "SELECT 1", LagWarning: 10, LagProblem: 60}, probe{Rows: true, Replica: true, Lag: 2}, StatusHealthy, "replication lag 2s"},
+		{"lag warning", dbSettings{Query: "SELECT 1", LagWarning: 10, LagProblem: 60}, probe{Rows: true, Replica: true, Lag: 30}, StatusWarning, "replication lag 30s over 10s"},
+		{"lag problem", dbSettings{Query: "SELECT 1", LagWarning: 10, LagProblem: 60}, probe{Rows: true, Replica: true, Lag: 90.4}, StatusProblem, "replication lag 1m30s over 60s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.s.result("db1:5432", tt.p, time.Millisecond)
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestDBSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    dbSettings
+		ok   bool
+	}{
+		{"ok", dbSettings{Port: 5432, Timeout: 10, Query: "SELECT 1"}, true},
+		{"port", dbSettings{Port: 0, Timeout: 10, Query: "SELECT 1"}, false},
+		{"timeout", dbSettings{Port: 5432, Query: "SELECT 1"}, false},
+		{"query", dbSettings{Port: 5432, Timeout: 10}, false},
+		{"expect", dbSettings{Port: 5432, Timeout: 10, Query: "SELECT 1", Expect: "("}, false},
+		{"negative lag", dbSettings{Port: 5432, Timeout: 10, Query: "SELECT 1", LagWarning: -1}, false},
+		{"lag order", dbSettings{Port: 5432, Timeout: 10, Query: "SELECT 1", LagWarning: 60, LagProblem: 10}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.s.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+func TestDBFailure(t *testing.T) {
+	r := dbFailure("db1:5432", "connection failed", time.Second, fmt.Errorf("attempt 1 failed\n\tattempt 2 failed"))
+	wantStatus(t, r, StatusProblem)
+	if r.Message != "db1:5432 - connection failed: attempt 1 failed attempt 2 failed" {
+		t.Errorf("got %q", r.Message)
+	}
+}
+
+// TestSQLConnectionFailed runs the SQL checks against a server that hangs up
+// on every connection
+func TestSQLConnectionFailed(t *testing.T) {
+	port := serve(t, func(net.Conn) {})
+
+	for _, service := range []string{ServicePostgres, ServiceMySQL} {
+		t.Run(service, func(t *testing.T) {
+			r := Run(target(service, models.Host{IP: "127.0.0.1"}, fmt.Sprintf(`{"port": %d, "timeout": 2}`, port)))
+			wantStatus(t, r, StatusProblem)
+			if want := fmt.Sprintf("127.0.0.1:%d - connection failed", port); !strings.Contains(r.Message, want) {
+				t.Errorf("message %q does not contain %q", r.Message, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceDNS is the name of the DNS service in the services table
+const ServiceDNS = "DNS"
+
+func init() {
+	Register(ServiceDNS, dnsChecker{})
+}
+
+// dnsSettings are the per-host-service settings for DNS checks
+type dnsSettings struct {
+	// Name to resolve; empty uses the host's canonical name, then its host name
+	Name string `json:"name"`
+	// RecordType is one of A, AAAA, CNAME, MX or TXT
+	RecordType string `json:"record_type"`
+	// Resolver is the server to query as host or host:port; empty uses the system resolver
+	Resolver string `json:"resolver"`
+	// Expected values; for A and AAAA it defaults to the host's IP or IPv6 address
+	Expected []string `json:"expected"`
+	// Match is all (every expected value is answered), any (at least one is) or
+	// exact (the answers are exactly the expected values)
+	Match string `json:"match"`
+	// Timeout is the query timeout in seconds
+	Timeout int `json:"timeout"`
+	// WarningMS reports a warning when resolution takes longer; 0 is not checked
+	WarningMS int `json:"warning_ms"`
+}
+
+// dnsChecker resolves a name and compares the answers with the expected records
+type dnsChecker struct{}
+
+// DefaultSettings returns the DNS check defaults
+func (dnsChecker) DefaultSettings() interface{} {
+	return &dnsSettings{RecordType: "A", Expected: []string{}, Match: "all", Timeout: 5, WarningMS: 1000}
+}
+
+// ignoresFamily: the record type, not the connection, decides the family
+func (dnsChecker) ignoresFamily() {}
+
+// validate reports settings that can never work
+func (s dnsSettings) validate() error {
+	switch strings.ToUpper(s.RecordType) {
+	case "A", "AAAA", "CNAME", "MX", "TXT":
+	default:
+		return fmt.Errorf("unsupported record type %q", s.RecordType)
+	}
+	switch strings.ToLower(s.Match) {
+	case "all", "any", "exact":
+	default:
+		return fmt.Errorf("match must be all, any or exact, not %q", s.Match)
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	return nil
+}
+
+// Check resolves the configured record and reports mismatches
+func (c dnsChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*dnsSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	name := s.Name
+	if name == "" {
+		name = t.Host.CanonicalName
+	}
+	if name == "" {
+		name = t.Host.HostName
+	}
+	if name == "" {
+		err := errors.New("no name to resolve")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+
+	recordType := strings.ToUpper(s.RecordType)
+	expected := s.Expected
+	if len(expected) == 0 {
+		switch recordType {
+		case "A":
+			if t.Host.IP != "" {
+				expected = []string{t.Host.IP}
+			}
+		case "AAAA":
+			if t.Host.IPV6 != "" {
+				expected = []string{t.Host.IPV6}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := s.lookup(ctx, recordType, name)
+	latency := time.Since(start)
+
+	label := fmt.Sprintf("%s %s", name, recordType)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - NXDOMAIN (no such name or no %s records)", label, recordType), Latency: latency, Err: err}
+		}
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - lookup failed: %s", label, err), Latency: latency, Err: err}
+	}
+
+	if len(answers) == 0 {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - no answers", label), Latency: latency}
+	}
+
+	if missing, extra := compareRecords(recordType, answers, expected, s.Match); len(missing) > 0 || len(extra) > 0 {
+		msg := fmt.Sprintf("%s - got %s", label, strings.Join(answers, ", "))
+		if len(missing) > 0 {
+			msg += fmt.Sprintf(", expected %s", strings.Join(missing, ", "))
+		}
+		if len(extra) > 0 {
+			msg += fmt.Sprintf(", unexpected %s", strings.Join(extra, ", "))
+		}
+		return Result{Status: StatusProblem, Message: msg, Latency: latency}
+	}
+
+	msg := fmt.Sprintf("%s - %s in %s", label, strings.Join(answers, ", "), roundLatency(latency))
+	if s.WarningMS > 0 && latency > time.Duration(s.WarningMS)*time.Millisecond {
+		return Result{Status: StatusWarning, Message: msg + fmt.Sprintf(" (slower than %dms)", s.WarningMS), Latency: latency}
+	}
+	return Result{Status: StatusHealthy, Message: msg, Latency: latency}
+}
+
+// lookup returns the answers for a record type in a normalized, sorted form
+func (s dnsSettings) lookup(ctx context.Context, recordType, name string) ([]string, error) {
+	r := s.resolver()
+
+	var answers []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, normalizeName(cname))
+	case "MX":
+		mxs, err := r.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, fmt.Sprintf("%d %s", mx.Pref, normalizeName(mx.Host)))
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// resolver returns the system resolver, or one that sends every query to the configured server
+func (s dnsSettings) resolver() *net.Resolver {
+	if s.Resolver == "" {
+		return net.DefaultResolver
+	}
+
+	server := s.Resolver
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: time.Duration(s.Timeout) * time.Second}
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// compareRecords checks the answers against the expected values, returning the
+// expected values that are missing and, for exact matching, the answers that were not expected
+func compareRecords(recordType string, answers, expected []string, match string) (missing, extra []string) {
+	if len(expected) == 0 {
+		return nil, nil
+	}
+
+	found := make(map[string]bool)
+	for _, e := range expected {
+		for _, a := range answers {
+			if recordMatches(recordType, a, e) {
+				found[e] = true
+			}
+		}
+	}
+
+	switch strings.ToLower(match) {
+	case "any":
+		if len(found) == 0 {
+			return expected, nil
+		}
+		return nil, nil
+	case "exact":
+		for _, a := range answers {
+			ok := false
+			for _, e := range expected {
+				if recordMatches(recordType, a, e) {
+					ok = true
+				}
+			}
+			if !ok {
+				extra = append(extra, a)
+			}
+		}
+	}
+
+	for _, e := range expected {
+		if !found[e] {
+			missing = append(missing, e)
+		}
+	}
+	return missing, extra
+}
+
+// recordMatches compares an answer with an expected value for a record type
+func recordMatches(recordType, answer, expected string) bool {
+	switch recordType {
+	case "A", "AAAA":
+		a, e := net.ParseIP(answer), net.ParseIP(expected)
+		return a != nil && e != nil && a.Equal(e)
+	case "CNAME":
+		return answer == normalizeName(expected)
+	case "MX":
+		//expected may be "10 mail.example.com" or just "mail.example.com"
+		pref, host, _ := strings.Cut(answer, " ")
+		if f := strings.Fields(expected); len(f) == 2 {
+			if _, err := strconv.Atoi(f[0]); err == nil {
+				return f[0] == pref && normalizeName(f[1]) == host
+			}
+		}
+		return normalizeName(expected) == host
+	default:
+		return answer == expected
+	}
+}
+
+// normalizeName lowercases a DNS name and removes the trailing dot
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
@@ -0,0 +1,141 @@
+package checkers
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestCompareRecords(t *testing.T) {
+	tests := []struct {
+		name        string
+		recordType  string
+		answers     []string
+		expected    []string
+		match       string
+		wantMissing []string
+		wantExtra   []string
+	}{
+		{"nothing expected", "A", []string{"192.0.2.1"}, nil, "all", nil, nil},
+		{"all present", "A", []string{"192.0.2.1", "192.0.2.2"}, []string{"192.0.2.2"}, "all", nil, nil},
+		{"all missing one", "A", []string{"192.0.2.1"}, []string{"192.0.2.1", "192.0.2.3"}, "all", []string{"192.0.2.3"}, nil},
+		{"any present", "A", []string{"192.0.2.1"}, []string{"192.0.2.1", "192.0.2.3"}, "any", nil, nil},
+		{"any none", "A", []string{"192.0.2.1"}, []string{"192.0.2.3"}, "any", []string{"192.0.2.3"}, nil},
+		{"exact extra", "A", []string{"192.0.2.1", "192.0.2.2"}, []string{"192.0.2.1"}, "exact", nil, []string{"192.0.2.2"}},
+		{"ipv6 forms", "AAAA", []string{"2001:db8::1"}, []string{"2001:0db8:0:0:0:0:0:1"}, "exact", nil, nil},
+		{"cname case and dot", "CNAME", []string{"www.example.com"}, []string{"WWW.Example.com."}, "all", nil, nil},
+		{"mx host only", "MX", []string{"10 mail.example.com"}, []string{"mail.example.com"}, "all", nil, nil},
+		{"mx preference", "MX", []string{"10 mail.example.com"}, []string{"20 mail.example.com"}, "all", []string{"20 mail.example.com"}, nil},
+		{"txt", "TXT", []string{"v=spf1 -all"}, []string{"v=spf1 -all"}, "exact", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, extra := compareRecords(tt.recordType, tt.answers, tt.expected, tt.match)
+			if strings.Join(missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("missing %v, want %v", missing, tt.wantMissing)
+			}
+			if strings.Join(extra, ",") != strings.Join(tt.wantExtra, ",") {
+				t.Errorf("extra %v, want %v", extra, tt.wantExtra)
+			}
+		})
+	}
+}
+
+func TestDNSSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    dnsSettings
+		ok   bool
+	}{
+		{"defaults", *dnsChecker{}.DefaultSettings().(*dnsSettings), true},
+		{"lowercase", dnsSettings{RecordType: "mx", Match: "Any", Timeout: 1}, true},
+		{"record type", dnsSettings{RecordType: "SRV", Match: "all", Timeout: 1}, false},
+		{"match", dnsSettings{RecordType: "A", Match: "some", Timeout: 1}, false},
+		{"timeout", dnsSettings{RecordType: "A", Match: "all"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.s.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+// resolver starts a DNS server on a local UDP port that answers A and MX
+// queries for web1.example.com and NXDOMAIN for everything else
+func resolver(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			q := query.Questions[0]
+
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+				Questions: query.Questions,
+			}
+			header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 60}
+			switch {
+			case q.Name.String() != "web1.example.com.":
+				reply.RCode = dnsmessage.RCodeNameError
+			case q.Type == dnsmessage.TypeA:
+				reply.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}}}}
+			case q.Type == dnsmessage.TypeMX:
+				reply.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.com.")}}}
+			}
+
+			packed, err := reply.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSChecker(t *testing.T) {
+	server := resolver(t)
+	host := models.Host{HostName: "web1.example.com", IP: "192.0.2.10"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"host ip", `{"resolver": "` + server + `"}`, StatusHealthy, "web1.example.com A - 192.0.2.10"},
+		{"wrong ip", `{"resolver": "` + server + `", "expected": ["192.0.2.11"]}`, StatusProblem, "expected 192.0.2.11"},
+		{"mx", `{"resolver": "` + server + `", "record_type": "MX", "expected": ["mail.example.com"]}`, StatusHealthy, "10 mail.example.com"},
+		{"nxdomain", `{"resolver": "` + server + `", "name": "web2.example.com"}`, StatusProblem, "NXDOMAIN"},
+		{"invalid", `{"record_type": "SRV"}`, StatusProblem, "invalid settings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceDNS, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
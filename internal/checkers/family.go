@@ -0,0 +1,156 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Package checkers contains address family selection: a host service is
+//checked over whichever family the system picks, over IPv4 or IPv6 only, or
+//over both, one check per family, so that a broken AAAA record shows up as a
+//warning while IPv4 still works
+
+// Address families a host service can be checked over
+const (
+	// FamilyAuto leaves the choice to the resolver and the host's addresses
+	FamilyAuto = "auto"
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+	// FamilyBoth checks each family separately and combines the results
+	FamilyBoth = "both"
+)
+
+// ValidFamily reports whether family is one of the address families
+func ValidFamily(family string) bool {
+	switch family {
+	case FamilyAuto, FamilyIPv4, FamilyIPv6, FamilyBoth:
+		return true
+	}
+	return false
+}
+
+// familyIndependent is implemented by checkers that do not connect to the
+// host, or choose their own address family, so one run answers for both
+type familyIndependent interface {
+	ignoresFamily()
+}
+
+// network restricts a network such as tcp or udp to the target's family
+func (t Target) network(base string) string {
+	switch t.Family {
+	case FamilyIPv4:
+		return base + "4"
+	case FamilyIPv6:
+		return base + "6"
+	}
+	return base
+}
+
+// dialContext returns a dial function for clients that choose the network
+// themselves, restricting it to the target's family
+func (t Target) dialContext(d *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" || network == "udp" {
+			network = t.network(network)
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// allows reports whether ip belongs to the target's family
+func (t Target) allows(ip net.IP) bool {
+	switch t.Family {
+	case FamilyIPv4:
+		return ip.To4() != nil
+	case FamilyIPv6:
+		return ip.To4() == nil
+	}
+	return true
+}
+
+// transport returns an HTTP transport like the default one that connects
+// over the target's family
+func (t Target) transport() *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = t.dialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+	return tr
+}
+
+// familyName names a family in messages
+func familyName(family string) string {
+	if family == FamilyIPv6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+// checkFamilies runs a check over IPv4 and IPv6 at the same time and combines
+// the results: one family down is a warning, both down a problem
+func checkFamilies(ctx context.Context, c Checker, t Target) Result {
+	families := []string{FamilyIPv4, FamilyIPv6}
+	results := make([]Result, len(families))
+
+	var wg sync.WaitGroup
+	for i, family := range families {
+		wg.Add(1)
+		go func(i int, ft Target) {
+			defer wg.Done()
+			start := time.Now()
+			r := c.Check(ctx, ft)
+			if r.Latency == 0 {
+				r.Latency = time.Since(start)
+			}
+			results[i] = applyThresholds(ft.HostService, r)
+		}(i, Target{Host: t.Host, HostService: t.HostService, Family: family})
+	}
+	wg.Wait()
+
+	combined := Result{Status: StatusHealthy, Metrics: make(map[string]float64)}
+	var messages, down []string
+	up := 0
+	for i, r := range results {
+		name := familyName(families[i])
+		prefix := families[i] + "_"
+
+		combined.Status = worstStatus(combined.Status, r.Status)
+		messages = append(messages, fmt.Sprintf("%s: %s", name, r.Message))
+		switch r.Status {
+		case StatusProblem:
+			down = append(down, name)
+		case StatusHealthy, StatusWarning:
+			up++
+		}
+
+		//Per-family metrics; checkers that already name the family keep their names
+		for k, v := range r.Metrics {
+			if !strings.HasPrefix(k, prefix) {
+				k = prefix + k
+			}
+			combined.Metrics[k] = v
+		}
+		combined.Metrics[prefix+"latency_ms"] = float64(r.Latency) / float64(time.Millisecond)
+
+		//Report the slower family as the latency, as ping does
+		if r.Latency > combined.Latency {
+			combined.Latency = r.Latency
+		}
+		if combined.Err == nil {
+			combined.Err = r.Err
+		}
+		if !r.CertExpiry.IsZero() && (combined.CertExpiry.IsZero() || r.CertExpiry.Before(combined.CertExpiry)) {
+			combined.CertExpiry = r.CertExpiry
+		}
+	}
+
+	combined.Message = strings.Join(messages, "; ")
+	if len(down) == 1 && up == 1 {
+		combined.Status = StatusWarning
+		combined.Message = fmt.Sprintf("%s is down - %s", down[0], combined.Message)
+	}
+	return combined
+}
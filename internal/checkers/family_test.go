@@ -0,0 +1,170 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestValidFamily(t *testing.T) {
+	for _, f := range []string{FamilyAuto, FamilyIPv4, FamilyIPv6, FamilyBoth} {
+		if !ValidFamily(f) {
+			t.Errorf("ValidFamily(%q) = false", f)
+		}
+	}
+	for _, f := range []string{"", "IPv4", "ipv5", "dual"} {
+		if ValidFamily(f) {
+			t.Errorf("ValidFamily(%q) = true", f)
+		}
+	}
+}
+
+func TestTargetFamily(t *testing.T) {
+	v4, v6 := net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")
+	host := models.Host{HostName: "web1", IP: "192.0.2.1", IPV6: "2001:db8::1"}
+
+	tests := []struct {
+		family   string
+		network  string
+		allows4  bool
+		allows6  bool
+		address  string
+		nameOnly string
+	}{
+		{"", "tcp", true, true, "192.0.2.1", "web1"},
+		{FamilyIPv4, "tcp4", true, false, "192.0.2.1", "web1"},
+		{FamilyIPv6, "tcp6", false, true, "2001:db8::1", "web1"},
+	}
+	for _, tt := range tests {
+		ft := Target{Host: host, Family: tt.family}
+		if got := ft.network("tcp"); got != tt.network {
+			t.Errorf("%q: network %s, want %s", tt.family, got, tt.network)
+		}
+		if ft.allows(v4) != tt.allows4 || ft.allows(v6) != tt.allows6 {
+			t.Errorf("%q: allows IPv4 %t, IPv6 %t", tt.family, ft.allows(v4), ft.allows(v6))
+		}
+		if got := hostAddress(ft); got != tt.address {
+			t.Errorf("%q: address %s, want %s", tt.family, got, tt.address)
+		}
+		//Without an address of the family the name is resolved instead
+		if got := hostAddress(Target{Host: models.Host{HostName: "web1"}, Family: tt.family}); got != tt.nameOnly {
+			t.Errorf("%q: address %s for a name only host, want %s", tt.family, got, tt.nameOnly)
+		}
+	}
+
+	//An IPv4 only host checked over IPv6 must not fall back to its IPv4 address
+	if got := hostAddress(Target{Host: models.Host{HostName: "web1", IP: "192.0.2.1"}, Family: FamilyIPv6}); got != "web1" {
+		t.Errorf("IPv6 address of an IPv4 host = %s, want web1", got)
+	}
+}
+
+// familyChecker returns a fixed result for each address family
+type familyChecker map[string]Result
+
+func (familyChecker) DefaultSettings() interface{} { return &struct{}{} }
+
+func (c familyChecker) Check(ctx context.Context, t Target) Result {
+	return c[t.Family]
+}
+
+func TestCheckFamilies(t *testing.T) {
+	healthy := Result{Status: StatusHealthy, Message: "ok", Latency: time.Millisecond, Metrics: map[string]float64{"rtt_ms": 1}}
+	slow := Result{Status: StatusWarning, Message: "slow", Latency: 3 * time.Millisecond}
+	down := Result{Status: StatusProblem, Message: "connection refused", Latency: 2 * time.Millisecond, Err: errors.New("refused")}
+
+	tests := []struct {
+		name    string
+		v4, v6  Result
+		status  string
+		message string
+	}{
+		{"both up", healthy, healthy, StatusHealthy, "IPv4: ok; IPv6: ok"},
+		{"ipv6 down", healthy, down, StatusWarning, "IPv6 is down - IPv4: ok; IPv6: connection refused"},
+		{"ipv4 down", down, slow, StatusWarning, "IPv4 is down - "},
+		{"both down", down, down, StatusProblem, "IPv4: connection refused; IPv6: connection refused"},
+		{"one slow", slow, healthy, StatusWarning, "IPv4: slow; IPv6: ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := familyChecker{FamilyIPv4: tt.v4, FamilyIPv6: tt.v6}
+			r := checkFamilies(context.Background(), c, Target{})
+			wantStatus(t, r, tt.status)
+			if !strings.HasPrefix(r.Message, tt.message) {
+				t.Errorf("message %q does not start with %q", r.Message, tt.message)
+			}
+			if want := max(tt.v4.Latency, tt.v6.Latency); r.Latency != want {
+				t.Errorf("latency %s, want the slower family's %s", r.Latency, want)
+			}
+		})
+	}
+
+	//Metrics are kept per family, without doubling a prefix the checker added
+	c := familyChecker{
+		FamilyIPv4: Result{Status: StatusHealthy, Latency: time.Millisecond, Metrics: map[string]float64{"ipv4_packets_sent": 3, "rtt_ms": 1}},
+		FamilyIPv6: Result{Status: StatusHealthy, Latency: time.Millisecond, Metrics: map[string]float64{"rtt_ms": 2}},
+	}
+	r := checkFamilies(context.Background(), c, Target{})
+	for k, v := range map[string]float64{"ipv4_packets_sent": 3, "ipv4_rtt_ms": 1, "ipv6_rtt_ms": 2, "ipv4_latency_ms": 1, "ipv6_latency_ms": 1} {
+		if r.Metrics[k] != v {
+			t.Errorf("metric %s = %v, want %v (all %v)", k, r.Metrics[k], v, r.Metrics)
+		}
+	}
+
+	//The earliest certificate expiry wins
+	soon, later := time.Now().Add(time.Hour), time.Now().Add(48*time.Hour)
+	c = familyChecker{
+		FamilyIPv4: Result{Status: StatusHealthy, CertExpiry: later},
+		FamilyIPv6: Result{Status: StatusHealthy, CertExpiry: soon},
+	}
+	if r := checkFamilies(context.Background(), c, Target{}); !r.CertExpiry.Equal(soon) {
+		t.Errorf("cert expiry %s, want %s", r.CertExpiry, soon)
+	}
+}
+
+func TestRunBothFamilies(t *testing.T) {
+	port := serve(t, func(net.Conn) {})
+	host := models.Host{IP: "127.0.0.1", IPV6: "::1"}
+
+	tg := target(ServiceTCP, host, fmt.Sprintf(`{"port": %d}`, port))
+	tg.HostService.AddressFamily = FamilyBoth
+	r := Run(tg)
+	wantStatus(t, r, StatusWarning)
+	if !strings.HasPrefix(r.Message, "IPv6 is down - ") {
+		t.Errorf("got %q", r.Message)
+	}
+
+	tg.HostService.AddressFamily = FamilyIPv4
+	wantStatus(t, Run(tg), StatusHealthy)
+	tg.HostService.AddressFamily = FamilyIPv6
+	wantStatus(t, Run(tg), StatusProblem)
+
+	//Family independent checkers run once
+	hb := target(ServiceHeartbeat, host, "")
+	hb.HostService.AddressFamily = FamilyBoth
+	if r := Run(hb); strings.Contains(r.Message, "IPv4:") {
+		t.Errorf("heartbeat checked per family: %q", r.Message)
+	}
+
+	l, err := net.Listen("tcp6", net.JoinHostPort("::1", fmt.Sprint(port)))
+	if err != nil {
+		t.Skipf("cannot listen on ::1: %s", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	tg.HostService.AddressFamily = FamilyBoth
+	wantStatus(t, Run(tg), StatusHealthy)
+}
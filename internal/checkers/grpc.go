@@ -0,0 +1,312 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// ServiceGRPC is the name of the gRPC health service in the services table
+const ServiceGRPC = "gRPC Health"
+
+// grpcHealthPath is the method of the standard gRPC health checking protocol
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// maxGRPCMessage is the largest health response read
+const maxGRPCMessage = 4096
+
+// Serving statuses of grpc.health.v1.HealthCheckResponse
+const (
+	grpcUnknown        = 0
+	grpcServing        = 1
+	grpcNotServing     = 2
+	grpcServiceUnknown = 3
+)
+
+// grpcCodes names the gRPC status codes a health call may fail with
+var grpcCodes = map[string]string{
+	"1":  "cancelled",
+	"2":  "unknown error",
+	"4":  "deadline exceeded",
+	"5":  "service not found",
+	"7":  "permission denied",
+	"12": "health checking not implemented",
+	"13": "internal error",
+	"14": "unavailable",
+	"16": "unauthenticated",
+}
+
+func init() {
+	Register(ServiceGRPC, grpcChecker{})
+}
+
+// grpcSettings are the per-host-service settings for gRPC health checks
+type grpcSettings struct {
+	// Port to connect to
+	Port int `json:"port"`
+	// Timeout for the call in seconds
+	Timeout int `json:"timeout"`
+	// Service is the name passed to Check; empty asks about the server as a whole
+	Service string `json:"service"`
+	// TLS connects over TLS; otherwise HTTP/2 is spoken in plain text
+	TLS bool `json:"tls"`
+	tlsSettings
+}
+
+// grpcChecker calls grpc.health.v1.Health/Check over HTTP/2
+type grpcChecker struct{}
+
+// DefaultSettings returns the gRPC health check defaults
+func (grpcChecker) DefaultSettings() interface{} {
+	return &grpcSettings{Port: 50051, Timeout: 10}
+}
+
+// validate reports settings that can never work
+func (s grpcSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	return nil
+}
+
+// Check calls the health service and maps SERVING, NOT_SERVING and UNKNOWN to
+// healthy, problem and warning
+func (c grpcChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*grpcSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	var verifyErr error
+	transport := &http2.Transport{}
+	scheme := "http"
+	if s.TLS {
+		tlsConfig, err := s.config(s.hostServerName(t), &verifyErr)
+		if err != nil {
+			return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+		}
+		tlsConfig.NextProtos = []string{"h2"}
+		transport.TLSClientConfig = tlsConfig
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, t.network(network), addr)
+			if err != nil {
+				return nil, err
+			}
+			tlsConn := tls.Client(conn, cfg)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+		scheme = "https"
+	} else {
+		//gRPC without TLS is HTTP/2 with prior knowledge
+		transport.AllowHTTP = true
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, t.network(network), addr)
+		}
+	}
+	defer transport.CloseIdleConnections()
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	u := url.URL{Scheme: scheme, Host: addr, Path: grpcHealthPath}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(grpcFrame(healthCheckRequest(s.Service))))
+	if err != nil {
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Grpc-Timeout", fmt.Sprintf("%dS", s.Timeout))
+	if name := s.hostServerName(t); name != "" {
+		req.Host = net.JoinHostPort(name, strconv.Itoa(s.Port))
+	}
+
+	target := addr
+	if s.Service != "" {
+		target = fmt.Sprintf("%s %s", addr, s.Service)
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		latency := time.Since(start)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - timed out after %s", target, timeout), Latency: latency, Err: err}
+		case isTLSError(err):
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", target, describeTLSError(err)), Latency: latency, Err: err}
+		}
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - call failed: %s", target, err), Latency: latency, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxGRPCMessage))
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - reading response: %s", target, err), Latency: latency, Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc") {
+		err := fmt.Errorf("not a gRPC response: %s", resp.Status)
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", target, err), Latency: latency, Err: err}
+	}
+
+	//Errors come in the trailers, or in the headers of a response without a body
+	code := grpcMetadata(resp, "Grpc-Status")
+	msg := grpcMetadata(resp, "Grpc-Message")
+	if code != "" && code != "0" {
+		reason := grpcCodes[code]
+		if reason == "" {
+			reason = "status " + code
+		}
+		if m, err := url.PathUnescape(msg); err == nil && m != "" {
+			reason = fmt.Sprintf("%s: %s", reason, m)
+		}
+		err := fmt.Errorf("grpc status %s", code)
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", target, reason), Latency: latency, Err: err}
+	}
+
+	status, err := healthCheckStatus(body)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - bad response: %s", target, err), Latency: latency, Err: err}
+	}
+
+	result := Result{Latency: latency}
+	switch status {
+	case grpcServing:
+		result.Status = StatusHealthy
+		result.Message = fmt.Sprintf("%s - SERVING in %s", target, roundLatency(latency))
+	case grpcNotServing:
+		result.Status = StatusProblem
+		result.Message = fmt.Sprintf("%s - NOT_SERVING", target)
+	case grpcServiceUnknown:
+		result.Status = StatusProblem
+		result.Message = fmt.Sprintf("%s - SERVICE_UNKNOWN, the server does not know this service", target)
+	default:
+		result.Status = StatusWarning
+		result.Message = fmt.Sprintf("%s - UNKNOWN", target)
+	}
+
+	//Answered, but only because verification was skipped
+	if verifyErr != nil {
+		result.Status = worstStatus(result.Status, StatusWarning)
+		result.Message += fmt.Sprintf(" (verification skipped: %s)", describeTLSError(verifyErr))
+		result.Err = verifyErr
+	}
+	return result
+}
+
+// grpcMetadata returns a trailer, or the header of the same name when the
+// response had no body and so no trailers
+func grpcMetadata(resp *http.Response, name string) string {
+	if v := resp.Trailer.Get(name); v != "" {
+		return v
+	}
+	return resp.Header.Get(name)
+}
+
+// healthCheckRequest encodes a grpc.health.v1.HealthCheckRequest, whose only
+// field is string service = 1
+func healthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	b := []byte{0x0a}
+	b = binary.AppendUvarint(b, uint64(len(service)))
+	return append(b, service...)
+}
+
+// grpcFrame prefixes a message with the gRPC length-prefixed message header:
+// an uncompressed flag and the length
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// healthCheckStatus decodes the ServingStatus status = 1 of a framed
+// grpc.health.v1.HealthCheckResponse; an absent field is UNKNOWN
+func healthCheckStatus(body []byte) (int, error) {
+	if len(body) < 5 {
+		return 0, errors.New("no message")
+	}
+	if body[0] != 0 {
+		return 0, errors.New("compressed responses are not supported")
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	if uint32(len(body)-5) < n {
+		return 0, errors.New("truncated message")
+	}
+	msg := body[5 : 5+n]
+
+	status := grpcUnknown
+	for len(msg) > 0 {
+		key, k := binary.Uvarint(msg)
+		if k <= 0 {
+			return 0, errors.New("malformed message")
+		}
+		msg = msg[k:]
+
+		switch key & 7 {
+		case 0: //varint
+			v, k := binary.Uvarint(msg)
+			if k <= 0 {
+				return 0, errors.New("malformed message")
+			}
+			msg = msg[k:]
+			if key>>3 == 1 {
+				status = int(v)
+			}
+		case 2: //length delimited
+			l, k := binary.Uvarint(msg)
+			if k <= 0 || uint64(len(msg)-k) < l {
+				return 0, errors.New("malformed message")
+			}
+			msg = msg[k+int(l):]
+		case 1: //64 bit
+			if len(msg) < 8 {
+				return 0, errors.New("malformed message")
+			}
+			msg = msg[8:]
+		case 5: //32 bit
+			if len(msg) < 4 {
+				return 0, errors.New("malformed message")
+			}
+			msg = msg[4:]
+		default:
+			return 0, errors.New("malformed message")
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,149 @@
+package checkers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestHealthCheckRequest(t *testing.T) {
+	tests := []struct {
+		service string
+		want    []byte
+	}{
+		{"", nil},
+		{"api", []byte{0x0a, 3, 'a', 'p', 'i'}},
+		{strings.Repeat("x", 200), append([]byte{0x0a, 0xc8, 0x01}, strings.Repeat("x", 200)...)},
+	}
+	for _, tt := range tests {
+		if got := healthCheckRequest(tt.service); !bytes.Equal(got, tt.want) {
+			t.Errorf("healthCheckRequest(%.10q) = %x, want %x", tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestHealthCheckStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want int
+		ok   bool
+	}{
+		{"serving", grpcFrame([]byte{0x08, 1}), grpcServing, true},
+		{"not serving", grpcFrame([]byte{0x08, 2}), grpcNotServing, true},
+		{"empty message", grpcFrame(nil), grpcUnknown, true},
+		{"unknown fields skipped", grpcFrame([]byte{0x12, 2, 'h', 'i', 0x19, 1, 2, 3, 4, 5, 6, 7, 8, 0x25, 1, 2, 3, 4, 0x08, 3}), grpcServiceUnknown, true},
+		{"trailing bytes ignored", append(grpcFrame([]byte{0x08, 1}), 0xff), grpcServing, true},
+		{"no message", []byte{0, 0, 0}, 0, false},
+		{"compressed", []byte{1, 0, 0, 0, 2, 0x08, 1}, 0, false},
+		{"truncated", []byte{0, 0, 0, 0, 5, 0x08, 1}, 0, false},
+		{"truncated varint", grpcFrame([]byte{0x08}), 0, false},
+		{"truncated field", grpcFrame([]byte{0x12, 5, 'h'}), 0, false},
+		{"bad wire type", grpcFrame([]byte{0x0b}), 0, false},
+	}
+	for _, tt := range tests {
+		got, err := healthCheckStatus(tt.body)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%s: got %d, %v; want %d, ok %t", tt.name, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// healthHandler answers grpc.health.v1.Health/Check: the server and "api" are
+// SERVING, "batch" is NOT_SERVING, "idle" has no status and anything else is
+// not found, sent as a trailers-only response
+func healthHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthPath || r.Header.Get("Content-Type") != "application/grpc" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("bad request frame %x", body)
+		}
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		var msg []byte
+		switch service {
+		case "", "api":
+			msg = []byte{0x08, grpcServing}
+		case "batch":
+			msg = []byte{0x08, grpcNotServing}
+		case "idle":
+		default:
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown%20service")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write(grpcFrame(msg))
+		w.Header().Set("Grpc-Status", "0")
+	})
+}
+
+func TestGRPCChecker(t *testing.T) {
+	plain := httptest.NewServer(h2c.NewHandler(healthHandler(t), &http2.Server{}))
+	defer plain.Close()
+
+	cert := testCert(t, time.Now().Add(24*time.Hour))
+	secure := httptest.NewUnstartedServer(healthHandler(t))
+	secure.EnableHTTP2 = true
+	secure.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	secure.Config.ErrorLog = log.New(io.Discard, "", 0)
+	secure.StartTLS()
+	defer secure.Close()
+	ca := caFile(t, cert.Leaf)
+
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+
+	port := func(srv *httptest.Server) int { return srv.Listener.Addr().(*net.TCPAddr).Port }
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"serving", fmt.Sprintf(`{"port": %d}`, port(plain)), StatusHealthy, "SERVING in"},
+		{"service serving", fmt.Sprintf(`{"port": %d, "service": "api"}`, port(plain)), StatusHealthy, "api - SERVING"},
+		{"not serving", fmt.Sprintf(`{"port": %d, "service": "batch"}`, port(plain)), StatusProblem, "batch - NOT_SERVING"},
+		{"no status", fmt.Sprintf(`{"port": %d, "service": "idle"}`, port(plain)), StatusWarning, "idle - UNKNOWN"},
+		{"not found", fmt.Sprintf(`{"port": %d, "service": "mail"}`, port(plain)), StatusProblem, "service not found: unknown service"},
+		{"tls", fmt.Sprintf(`{"port": %d, "tls": true, "ca_file": %q}`, port(secure), ca), StatusHealthy, "SERVING"},
+		{"tls untrusted", fmt.Sprintf(`{"port": %d, "tls": true}`, port(secure)), StatusProblem, "unknown authority"},
+		{"tls skip verify", fmt.Sprintf(`{"port": %d, "tls": true, "skip_verify": true}`, port(secure)), StatusWarning, "verification skipped"},
+		{"not grpc", fmt.Sprintf(`{"port": %d}`, port(web)), StatusProblem, "call failed"},
+		{"connection refused", fmt.Sprintf(`{"port": %d}`, closedPort(t)), StatusProblem, "call failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceGRPC, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ServiceHeartbeat is the name of the heartbeat service in the services table
+const ServiceHeartbeat = "Heartbeat"
+
+// Heartbeat ping statuses, stored as the host service's last ping status
+const (
+	PingSuccess = "success"
+	PingFail    = "fail"
+)
+
+func init() {
+	Register(ServiceHeartbeat, heartbeatChecker{})
+}
+
+// heartbeatSettings are the per-host-service settings for heartbeat checks
+type heartbeatSettings struct {
+	// Interval is how often the job is expected to ping, in seconds
+	Interval int `json:"interval"`
+	// Grace is how long a ping may be late, and how long a started run may
+	// take, before the service is a problem, in seconds
+	Grace int `json:"grace"`
+}
+
+// heartbeatChecker is a passive check: the watched job pings Vigilate, and the
+// check reports a problem when the pings stop or the job reports a failure
+type heartbeatChecker struct{}
+
+// DefaultSettings returns the heartbeat check defaults
+func (heartbeatChecker) DefaultSettings() interface{} {
+	return &heartbeatSettings{Interval: 3600, Grace: 300}
+}
+
+// validate reports settings that can never work
+func (s heartbeatSettings) validate() error {
+	if s.Interval < 1 {
+		return errors.New("interval must be at least one second")
+	}
+	if s.Grace < 1 {
+		return errors.New("grace must be at least one second")
+	}
+	return nil
+}
+
+// ignoresFamily: heartbeats are pushed to us, so there is nothing to connect to
+func (heartbeatChecker) ignoresFamily() {}
+
+// Check compares the time of the last ping with the expected interval
+func (c heartbeatChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*heartbeatSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	hs := t.HostService
+	now := time.Now()
+	interval := time.Duration(s.Interval) * time.Second
+	grace := time.Duration(s.Grace) * time.Second
+
+	//A run that started after the last ping has not finished yet
+	if pinged(hs.PingStartedAt) && hs.PingStartedAt.After(hs.LastPingAt) {
+		running := now.Sub(hs.PingStartedAt)
+		if running > grace {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("run started %s ago and has not finished (grace %s)", roundAge(running), grace)}
+		}
+		if pinged(hs.LastPingAt) && hs.LastPingStatus == PingFail {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("running for %s; the last run failed %s ago", roundAge(running), roundAge(now.Sub(hs.LastPingAt)))}
+		}
+		return Result{Status: hs.Status, Message: fmt.Sprintf("running for %s", roundAge(running))}
+	}
+
+	if !pinged(hs.LastPingAt) {
+		//The first ping is due an interval after the service was switched on
+		if pinged(hs.ActivatedAt) {
+			if waited := now.Sub(hs.ActivatedAt); waited > interval+grace {
+				return Result{Status: StatusProblem, Message: fmt.Sprintf("no ping in the %s since the service was enabled (expected every %s, grace %s)", roundAge(waited), interval, grace)}
+			}
+		}
+		return Result{Status: StatusPending, Message: "waiting for the first ping"}
+	}
+
+	age := now.Sub(hs.LastPingAt)
+	if age > interval+grace {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("no ping for %s (expected every %s, grace %s)", roundAge(age), interval, grace)}
+	}
+	if hs.LastPingStatus == PingFail {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("the job reported a failure %s ago", roundAge(age))}
+	}
+	return Result{Status: StatusHealthy, Message: fmt.Sprintf("last ping %s ago", roundAge(age))}
+}
+
+// pinged reports whether a ping time is set; unset times are stored as the year one
+func pinged(t time.Time) bool {
+	return t.Year() > 1
+}
+
+// roundAge rounds a duration to the second for display
+func roundAge(d time.Duration) time.Duration {
+	return d.Round(time.Second)
+}
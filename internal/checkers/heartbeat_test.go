@@ -0,0 +1,75 @@
+package checkers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestHeartbeatChecker(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name      string
+		status    string
+		lastPing  time.Time
+		pingState string
+		started   time.Time
+		activated time.Time
+		want      string
+		message   string
+	}{
+		{"never pinged", StatusPending, time.Time{}, "", time.Time{}, time.Time{}, StatusPending, "waiting for the first ping"},
+		{"first ping due", StatusPending, time.Time{}, "", time.Time{}, ago(time.Hour), StatusPending, "waiting for the first ping"},
+		{"first ping late", StatusPending, time.Time{}, "", time.Time{}, ago(2 * time.Hour), StatusProblem, "no ping in the 2h0m0s since the service was enabled"},
+		{"recent ping", StatusPending, ago(time.Minute), PingSuccess, time.Time{}, time.Time{}, StatusHealthy, "last ping 1m0s ago"},
+		{"within grace", StatusHealthy, ago(62 * time.Minute), PingSuccess, time.Time{}, time.Time{}, StatusHealthy, "last ping"},
+		{"late", StatusHealthy, ago(2 * time.Hour), PingSuccess, time.Time{}, time.Time{}, StatusProblem, "no ping for 2h0m0s"},
+		{"reported failure", StatusHealthy, ago(time.Minute), PingFail, time.Time{}, time.Time{}, StatusProblem, "reported a failure"},
+		{"running", StatusHealthy, ago(time.Hour), PingSuccess, ago(time.Minute), time.Time{}, StatusHealthy, "running for 1m0s"},
+		{"first run", StatusPending, time.Time{}, "", ago(time.Minute), time.Time{}, StatusPending, "running for"},
+		{"running too long", StatusHealthy, ago(time.Hour), PingSuccess, ago(10 * time.Minute), time.Time{}, StatusProblem, "has not finished"},
+		{"running after failure", StatusProblem, ago(time.Hour), PingFail, ago(time.Minute), time.Time{}, StatusProblem, "the last run failed"},
+		{"stale start", StatusHealthy, ago(time.Minute), PingSuccess, ago(time.Hour), time.Time{}, StatusHealthy, "last ping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := target(ServiceHeartbeat, models.Host{}, "")
+			tg.HostService.Status = tt.status
+			tg.HostService.LastPingAt = tt.lastPing
+			tg.HostService.LastPingStatus = tt.pingState
+			tg.HostService.PingStartedAt = tt.started
+			tg.HostService.ActivatedAt = tt.activated
+
+			r := Run(tg)
+			wantStatus(t, r, tt.want)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestHeartbeatValidate(t *testing.T) {
+	tests := []struct {
+		settings string
+		wantErr  string
+	}{
+		{`{"interval": 60, "grace": 10}`, ""},
+		{`{"interval": 0}`, "interval must be at least one second"},
+		{`{"interval": -60}`, "interval must be at least one second"},
+		{`{"grace": 0}`, "grace must be at least one second"},
+		{`{"grace": -1}`, "grace must be at least one second"},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(ServiceHeartbeat, tt.settings)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: got %v, want no error", tt.settings, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %v, want an error containing %q", tt.settings, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServiceHTTP is the name of the HTTP service in the services table
+const ServiceHTTP = "HTTP"
+
+func init() {
+	Register(ServiceHTTP, httpChecker{})
+}
+
+// httpSettings are the per-host-service settings for HTTP checks
+type httpSettings struct {
+	// Timeout is the request timeout in seconds
+	Timeout int `json:"timeout"`
+	requestSettings
+}
+
+// httpChecker performs a request against the host URL and checks the response
+type httpChecker struct{}
+
+// DefaultSettings returns the HTTP check defaults
+func (httpChecker) DefaultSettings() interface{} {
+	return &httpSettings{Timeout: 10, requestSettings: defaultRequestSettings()}
+}
+
+// validate reports settings that can never work, before any request is made
+func (s httpSettings) validate() error {
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	return s.requestSettings.validate()
+}
+
+// Check performs an HTTP request to see if the host is reachable and responds as expected
+func (c httpChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*httpSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	//Normalize URL : remove trailing slash
+	url := strings.TrimSuffix(t.Host.URL, "/")
+
+	//Convert https to http
+	url = strings.Replace(url, "https://", "http://", -1)
+	url = s.url(url)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := s.newRequest(ctx, url)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, err), Err: err}
+	}
+
+	transport := t.transport()
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Transport: transport, CheckRedirect: s.checkRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errTooManyRedirects) {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, errors.Unwrap(err)), Err: err}
+		}
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, "error connecting"), Err: err}
+	}
+	defer resp.Body.Close()
+
+	//Check the status code and assertions
+	if reason, err := s.verify(resp); reason != "" {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, reason), Err: err}
+	}
+
+	return Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - %s", url, resp.Status)}
+}
@@ -0,0 +1,367 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxBodyRead is the most of a response body read for assertions
+const maxBodyRead = 10 << 20
+
+// errTooManyRedirects is returned by a request that hit the redirect limit
+var errTooManyRedirects = errors.New("too many redirects")
+
+// Assertion types for HTTP and HTTPS checks
+const (
+	AssertContains    = "contains"
+	AssertNotContains = "not_contains"
+	AssertRegex       = "regex"
+	AssertJSONPath    = "json_path"
+	AssertHeader      = "header"
+	AssertMaxSize     = "max_size"
+)
+
+// requestSettings are the request and response settings shared by HTTP and HTTPS checks
+type requestSettings struct {
+	// Method is the request method
+	Method string `json:"method"`
+	// Path is appended to the host URL
+	Path string `json:"path"`
+	// Headers are added to the request
+	Headers map[string]string `json:"headers"`
+	// Body is sent with the request
+	Body string `json:"body"`
+	// AcceptedStatus lists the healthy status codes: single codes ("200"),
+	// ranges ("200-299") or classes ("2xx")
+	AcceptedStatus []string `json:"accepted_status"`
+	// FollowRedirects follows up to MaxRedirects redirects; otherwise the
+	// redirect response itself is checked
+	FollowRedirects bool `json:"follow_redirects"`
+	MaxRedirects    int  `json:"max_redirects"`
+	// Assertions are checked in order against the response
+	Assertions []assertion `json:"assertions"`
+}
+
+// assertion is a single check made against a response
+type assertion struct {
+	// Type is one of contains, not_contains, regex, json_path, header or max_size
+	Type string `json:"type"`
+	// Path is the JSONPath for json_path, e.g. $.status or $.items[0].name
+	Path string `json:"path,omitempty"`
+	// Name is the header name for header
+	Name string `json:"name,omitempty"`
+	// Value is the text, pattern, expected value, or size in bytes for max_size
+	Value string `json:"value"`
+}
+
+// String describes the assertion for check messages
+func (a assertion) String() string {
+	switch a.Type {
+	case AssertJSONPath:
+		return fmt.Sprintf("%s %s == %q", a.Type, a.Path, a.Value)
+	case AssertHeader:
+		return fmt.Sprintf("%s %s == %q", a.Type, a.Name, a.Value)
+	case AssertMaxSize:
+		return fmt.Sprintf("%s %s bytes", a.Type, a.Value)
+	default:
+		return fmt.Sprintf("%s %q", a.Type, a.Value)
+	}
+}
+
+// defaultRequestSettings keeps the original behavior: a GET where only 200 is healthy
+func defaultRequestSettings() requestSettings {
+	return requestSettings{
+		Method:          http.MethodGet,
+		Headers:         map[string]string{},
+		AcceptedStatus:  []string{"200"},
+		FollowRedirects: true,
+		MaxRedirects:    10,
+		Assertions:      []assertion{},
+	}
+}
+
+// url appends the configured path to a base URL
+func (s requestSettings) url(base string) string {
+	if s.Path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(s.Path, "/")
+}
+
+// newRequest builds the configured request for url
+func (s requestSettings) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	method := strings.ToUpper(s.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if s.Body != "" {
+		body = strings.NewReader(s.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Headers {
+		//Host is not a header as far as net/http is concerned
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// checkRedirect applies the redirect policy; use it as http.Client.CheckRedirect
+func (s requestSettings) checkRedirect(req *http.Request, via []*http.Request) error {
+	if !s.FollowRedirects {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= s.MaxRedirects {
+		return fmt.Errorf("%w: stopped after %d", errTooManyRedirects, s.MaxRedirects)
+	}
+	return nil
+}
+
+// validate reports settings that can never work, before any request is made
+func (s requestSettings) validate() error {
+	for _, a := range s.AcceptedStatus {
+		if _, _, err := statusRange(a); err != nil {
+			return err
+		}
+	}
+	for i, a := range s.Assertions {
+		switch a.Type {
+		case AssertContains, AssertNotContains:
+		case AssertRegex:
+			if _, err := regexp.Compile(a.Value); err != nil {
+				return fmt.Errorf("assertion %d: %w", i+1, err)
+			}
+		case AssertJSONPath:
+			if _, err := parseJSONPath(a.Path); err != nil {
+				return fmt.Errorf("assertion %d: %w", i+1, err)
+			}
+		case AssertHeader:
+			if a.Name == "" {
+				return fmt.Errorf("assertion %d: header assertions need a name", i+1)
+			}
+		case AssertMaxSize:
+			if n, err := strconv.Atoi(a.Value); err != nil || n < 0 {
+				return fmt.Errorf("assertion %d: max_size needs a number of bytes", i+1)
+			}
+		default:
+			return fmt.Errorf("assertion %d: unknown type %q", i+1, a.Type)
+		}
+	}
+	return nil
+}
+
+// accepted reports whether a status code is one of the accepted ones
+func (s requestSettings) accepted(code int) bool {
+	for _, a := range s.AcceptedStatus {
+		lo, hi, err := statusRange(a)
+		if err == nil && code >= lo && code <= hi {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRange parses "200", "200-299" or "2xx" into an inclusive range
+func statusRange(v string) (int, int, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+
+	if len(v) == 3 && strings.HasSuffix(v, "xx") {
+		if d, err := strconv.Atoi(v[:1]); err == nil {
+			return d * 100, d*100 + 99, nil
+		}
+	}
+
+	if lo, hi, ok := strings.Cut(v, "-"); ok {
+		l, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		h, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 == nil && err2 == nil && l <= h {
+			return l, h, nil
+		}
+	}
+
+	if c, err := strconv.Atoi(v); err == nil {
+		return c, c, nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid accepted status %q", v)
+}
+
+// verify checks the status code and runs the assertions against a response;
+// it returns why the response is a problem, or an empty string when it is not
+func (s requestSettings) verify(resp *http.Response) (string, error) {
+	if !s.accepted(resp.StatusCode) {
+		return resp.Status, nil
+	}
+	if len(s.Assertions) == 0 {
+		return "", nil
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Sprintf("%s, reading body: %s", resp.Status, err), err
+	}
+	return s.assert(resp, body), nil
+}
+
+// assert runs the assertions against a response whose body has been read,
+// describing the first one that fails
+func (s requestSettings) assert(resp *http.Response, body []byte) string {
+	for i, a := range s.Assertions {
+		if failure := a.check(resp, body); failure != "" {
+			return fmt.Sprintf("%s, assertion %d failed: %s (%s)", resp.Status, i+1, a, failure)
+		}
+	}
+	return ""
+}
+
+// readBody reads a response body, up to one byte more than maxBodyRead
+func readBody(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodyRead+1))
+}
+
+// check returns why the assertion fails for a response, or an empty string if it passes
+func (a assertion) check(resp *http.Response, body []byte) string {
+	switch a.Type {
+	case AssertContains:
+		if !bytes.Contains(body, []byte(a.Value)) {
+			return "not found in body"
+		}
+	case AssertNotContains:
+		if bytes.Contains(body, []byte(a.Value)) {
+			return "found in body"
+		}
+	case AssertRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return err.Error()
+		}
+		if !re.Match(body) {
+			return "no match in body"
+		}
+	case AssertJSONPath:
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "body is not JSON"
+		}
+		got, err := jsonPath(doc, a.Path)
+		if err != nil {
+			return err.Error()
+		}
+		if got != a.Value {
+			return fmt.Sprintf("got %q", truncate(got, 80))
+		}
+	case AssertHeader:
+		if got := resp.Header.Get(a.Name); got != a.Value {
+			return fmt.Sprintf("got %q", got)
+		}
+	case AssertMaxSize:
+		max, _ := strconv.Atoi(a.Value)
+		if len(body) > maxBodyRead {
+			return fmt.Sprintf("body is over %d bytes", maxBodyRead)
+		}
+		if len(body) > max {
+			return fmt.Sprintf("body is %d bytes", len(body))
+		}
+	default:
+		return "unknown assertion type"
+	}
+	return ""
+}
+
+// jsonPath returns the value at path in doc, formatted for comparison:
+// strings as they are, everything else as compact JSON
+// Supported: $, .name, ['name'] and [index]
+func jsonPath(doc interface{}, path string) (string, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	cur := doc
+	for _, step := range steps {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[step]
+			if !ok {
+				return "", fmt.Errorf("%s not found", path)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("%s not found", path)
+			}
+			cur = v[i]
+		default:
+			return "", fmt.Errorf("%s not found", path)
+		}
+	}
+
+	if s, ok := cur.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseJSONPath splits a JSONPath expression into object keys and array indexes
+func parseJSONPath(path string) ([]string, error) {
+	p := strings.TrimSpace(path)
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("json path %q must start with $", path)
+	}
+	p = p[1:]
+
+	var steps []string
+	for p != "" {
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q", path)
+			}
+			steps = append(steps, p[:end])
+			p = p[end:]
+		case p[0] == '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid json path %q", path)
+			}
+			key := strings.TrimSpace(p[1:end])
+			if len(key) >= 2 && (key[0] == '\'' || key[0] == '"') && key[len(key)-1] == key[0] {
+				key = key[1 : len(key)-1]
+			} else if _, err := strconv.Atoi(key); err != nil {
+				return nil, fmt.Errorf("invalid json path %q", path)
+			}
+			steps = append(steps, key)
+			p = p[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid json path %q", path)
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,150 @@
+package checkers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusRange(t *testing.T) {
+	tests := []struct {
+		v      string
+		lo, hi int
+		ok     bool
+	}{
+		{"200", 200, 200, true},
+		{" 204 ", 204, 204, true},
+		{"200-299", 200, 299, true},
+		{"300 - 399", 300, 399, true},
+		{"2xx", 200, 299, true},
+		{"5XX", 500, 599, true},
+		{"299-200", 0, 0, false},
+		{"xxx", 0, 0, false},
+		{"ok", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lo, hi, err := statusRange(tt.v)
+		if (err == nil) != tt.ok || lo != tt.lo || hi != tt.hi {
+			t.Errorf("statusRange(%q) = %d, %d, %v; want %d, %d, ok %t", tt.v, lo, hi, err, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	s := requestSettings{AcceptedStatus: []string{"200", "3xx", "401-403"}}
+	for code, want := range map[int]bool{200: true, 201: false, 301: true, 402: true, 404: false} {
+		if got := s.accepted(code); got != want {
+			t.Errorf("accepted(%d) = %t, want %t", code, got, want)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	var doc interface{}
+	body := `{"status": "ok", "count": 3, "up": true, "items": [{"name": "a"}, {"name": "b"}], "odd key": {"x": null}}`
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"$.status", "ok", true},
+		{"$.count", "3", true},
+		{"$.up", "true", true},
+		{"$.items[1].name", "b", true},
+		{"$.items[0]", `{"name":"a"}`, true},
+		{"$['odd key'].x", "null", true},
+		{`$["status"]`, "ok", true},
+		{"$", "", true},
+		{"$.missing", "", false},
+		{"$.items[2]", "", false},
+		{"$.items.name", "", false},
+		{"$.status.length", "", false},
+		{"status", "", false},
+		{"$..status", "", false},
+		{"$.items[x]", "", false},
+	}
+	for _, tt := range tests {
+		got, err := jsonPath(doc, tt.path)
+		if (err == nil) != tt.ok {
+			t.Errorf("jsonPath(%q) error %v, want ok %t", tt.path, err, tt.ok)
+			continue
+		}
+		if tt.ok && tt.want != "" && got != tt.want {
+			t.Errorf("jsonPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := `{"status": "ok", "version": "1.2.3"}`
+
+	tests := []struct {
+		name       string
+		accepted   []string
+		assertions []assertion
+		want       string
+	}{
+		{"no assertions", []string{"200"}, nil, ""},
+		{"status not accepted", []string{"201"}, nil, "200 OK"},
+		{"contains", []string{"200"}, []assertion{{Type: AssertContains, Value: `"ok"`}}, ""},
+		{"contains fails", []string{"200"}, []assertion{{Type: AssertContains, Value: "error"}}, "assertion 1 failed: contains \"error\" (not found in body)"},
+		{"not contains", []string{"200"}, []assertion{{Type: AssertNotContains, Value: "error"}}, ""},
+		{"not contains fails", []string{"200"}, []assertion{{Type: AssertNotContains, Value: "ok"}}, "found in body"},
+		{"regex", []string{"200"}, []assertion{{Type: AssertRegex, Value: `\d+\.\d+\.\d+`}}, ""},
+		{"regex fails", []string{"200"}, []assertion{{Type: AssertRegex, Value: `^ok$`}}, "no match in body"},
+		{"json path", []string{"200"}, []assertion{{Type: AssertJSONPath, Path: "$.status", Value: "ok"}}, ""},
+		{"json path fails", []string{"200"}, []assertion{{Type: AssertJSONPath, Path: "$.status", Value: "down"}}, `got "ok"`},
+		{"header", []string{"200"}, []assertion{{Type: AssertHeader, Name: "content-type", Value: "application/json"}}, ""},
+		{"header fails", []string{"200"}, []assertion{{Type: AssertHeader, Name: "X-Version", Value: "2"}}, `got ""`},
+		{"max size", []string{"200"}, []assertion{{Type: AssertMaxSize, Value: "100"}}, ""},
+		{"max size fails", []string{"200"}, []assertion{{Type: AssertMaxSize, Value: "10"}}, "body is 36 bytes"},
+		{"first failure", []string{"200"}, []assertion{{Type: AssertContains, Value: "ok"}, {Type: AssertContains, Value: "down"}}, "assertion 2 failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}
+			s := requestSettings{AcceptedStatus: tt.accepted, Assertions: tt.assertions}
+			got, err := s.verify(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    requestSettings
+		ok   bool
+	}{
+		{"defaults", defaultRequestSettings(), true},
+		{"status class", requestSettings{AcceptedStatus: []string{"2xx", "301-302"}}, true},
+		{"bad status", requestSettings{AcceptedStatus: []string{"two hundred"}}, false},
+		{"bad regex", requestSettings{Assertions: []assertion{{Type: AssertRegex, Value: "("}}}, false},
+		{"bad json path", requestSettings{Assertions: []assertion{{Type: AssertJSONPath, Path: "status"}}}, false},
+		{"header without name", requestSettings{Assertions: []assertion{{Type: AssertHeader, Value: "x"}}}, false},
+		{"negative max size", requestSettings{Assertions: []assertion{{Type: AssertMaxSize, Value: "-1"}}}, false},
+		{"unknown type", requestSettings{Assertions: []assertion{{Type: "equals"}}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.s.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package checkers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vigilate/internal/models"
+)
+
+func TestHTTPChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		settings string
+		want     string
+	}{
+		{"ok", srv.URL, "", StatusHealthy},
+		{"server error", srv.URL, `{"path": "/down"}`, StatusProblem},
+		{"server error accepted", srv.URL, `{"path": "/down", "accepted_status": ["5xx"]}`, StatusHealthy},
+		{"assertion", srv.URL, `{"assertions": [{"type": "max_size", "value": "0"}]}`, StatusHealthy},
+		{"invalid settings", srv.URL, `{"timeout": 0}`, StatusProblem},
+		{"connection refused", closed.URL, "", StatusProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceHTTP, models.Host{URL: tt.url}, tt.settings))
+			wantStatus(t, r, tt.want)
+		})
+	}
+}
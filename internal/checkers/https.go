@@ -0,0 +1,128 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServiceHTTPS is the name of the HTTPS service in the services table
+const ServiceHTTPS = "HTTPS"
+
+func init() {
+	Register(ServiceHTTPS, httpsChecker{})
+}
+
+// httpsSettings are the per-host-service settings for HTTPS checks
+type httpsSettings struct {
+	// Timeout is the request timeout in seconds
+	Timeout int `json:"timeout"`
+	requestSettings
+	tlsSettings
+}
+
+// httpsChecker performs a request over TLS, verifying the certificate
+// chain and host name
+type httpsChecker struct{}
+
+// DefaultSettings returns the HTTPS check defaults
+func (httpsChecker) DefaultSettings() interface{} {
+	return &httpsSettings{Timeout: 10, requestSettings: defaultRequestSettings()}
+}
+
+// validate reports settings that can never work, before any request is made
+func (s httpsSettings) validate() error {
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	return s.requestSettings.validate()
+}
+
+// Check performs an HTTPS request with certificate verification and checks the response
+func (c httpsChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*httpsSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	url := s.url(httpsURL(t.Host.URL))
+
+	var verifyErr error
+	tlsConfig, err := s.config(s.serverNameOverride(t), &verifyErr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, err), Err: err}
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         t.dialContext(&net.Dialer{Timeout: time.Duration(s.Timeout) * time.Second}),
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: time.Duration(s.Timeout) * time.Second,
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: s.checkRedirect,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := s.newRequest(ctx, url)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, err), Err: err}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errTooManyRedirects) {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, errors.Unwrap(err)), Err: err}
+		}
+		if isTLSError(err) {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, describeTLSError(err)), Err: err}
+		}
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, "error connecting"), Err: err}
+	}
+	defer resp.Body.Close()
+
+	var expiry time.Time
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiry = resp.TLS.PeerCertificates[0].NotAfter
+	}
+
+	if reason, err := s.verify(resp); reason != "" {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", url, reason), Err: err, CertExpiry: expiry}
+	}
+
+	//Reachable, but only because verification was skipped
+	if verifyErr != nil {
+		return Result{
+			Status:     StatusWarning,
+			Message:    fmt.Sprintf("%s - %s (verification skipped: %s)", url, resp.Status, describeTLSError(verifyErr)),
+			Err:        verifyErr,
+			CertExpiry: expiry,
+		}
+	}
+
+	return Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - %s, certificate valid", url, resp.Status), CertExpiry: expiry}
+}
+
+// httpsURL normalizes a host URL to use the https scheme
+func httpsURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		return url
+	case strings.HasPrefix(url, "http://"):
+		return "https://" + strings.TrimPrefix(url, "http://")
+	default:
+		return "https://" + url
+	}
+}
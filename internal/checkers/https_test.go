@@ -0,0 +1,73 @@
+package checkers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+)
+
+// caFile writes cert to a PEM file for the ca_file setting and returns its path
+func caFile(t *testing.T, cert *x509.Certificate) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHTTPSChecker(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	ca := caFile(t, srv.Certificate())
+
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+		message  string
+	}{
+		{"trusted", fmt.Sprintf(`{"ca_file": %q}`, ca), StatusHealthy, "certificate valid"},
+		{"untrusted", "", StatusProblem, "unknown authority"},
+		{"skip verify", `{"skip_verify": true}`, StatusWarning, "verification skipped"},
+		{"wrong name", fmt.Sprintf(`{"ca_file": %q, "server_name": "vigilate.invalid"}`, ca), StatusProblem, "vigilate.invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceHTTPS, models.Host{URL: srv.URL}, tt.settings))
+			wantStatus(t, r, tt.want)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not mention %q", r.Message, tt.message)
+			}
+			if r.CertExpiry.IsZero() && tt.want != StatusProblem {
+				t.Error("certificate expiry not reported")
+			}
+		})
+	}
+}
+
+func TestHTTPSURL(t *testing.T) {
+	tests := map[string]string{
+		"example.com":          "https://example.com",
+		"http://example.com/":  "https://example.com",
+		"https://example.com":  "https://example.com",
+		" https://example.com": "https://example.com",
+	}
+	for in, want := range tests {
+		if got := httpsURL(in); got != want {
+			t.Errorf("httpsURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ServiceIMAP is the name of the IMAP service in the services table
+const ServiceIMAP = "IMAP"
+
+func init() {
+	Register(ServiceIMAP, imapChecker{})
+}
+
+// imapChecker talks to an IMAP server: greeting, CAPABILITY, STARTTLS and LOGIN
+type imapChecker struct{}
+
+// DefaultSettings returns the IMAP check defaults
+func (imapChecker) DefaultSettings() interface{} {
+	return &mailSettings{Port: 143, Timeout: 20, Security: SecurityNone, Capabilities: true}
+}
+
+// Check holds an IMAP conversation up to logging in
+func (c imapChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*mailSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	return checkMail(ctx, t, *s, &imapProtocol{})
+}
+
+// imapProtocol is the IMAP conversation; commands are tagged a1, a2, ...
+type imapProtocol struct {
+	tag int
+}
+
+// command sends a tagged command and reads up to its tagged OK, returning the untagged responses
+func (p *imapProtocol) command(m *mailConn, cmd string) ([]string, error) {
+	p.tag++
+	tag := fmt.Sprintf("a%d", p.tag)
+	if err := m.writeLine(tag + " " + cmd); err != nil {
+		return nil, err
+	}
+
+	var untagged []string
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "* ") {
+			untagged = append(untagged, line[2:])
+			continue
+		}
+		rest, ok := strings.CutPrefix(line, tag+" ")
+		if !ok {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToUpper(rest), "OK") {
+			return nil, errors.New(truncate(rest, 200))
+		}
+		return untagged, nil
+	}
+}
+
+// greeting reads the untagged OK (or PREAUTH) greeting
+func (p *imapProtocol) greeting(m *mailConn) (string, error) {
+	line, err := m.readLine()
+	if err != nil {
+		return "", err
+	}
+	upper := strings.ToUpper(line)
+	if !strings.HasPrefix(upper, "* OK") && !strings.HasPrefix(upper, "* PREAUTH") {
+		return "", errors.New(truncate(line, 200))
+	}
+	return strings.TrimPrefix(line, "* "), nil
+}
+
+// capabilities sends CAPABILITY and returns each capability, such as AUTH=PLAIN
+func (p *imapProtocol) capabilities(m *mailConn) ([]string, error) {
+	untagged, err := p.command(m, "CAPABILITY")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range untagged {
+		if f := strings.Fields(u); len(f) > 0 && strings.EqualFold(f[0], "CAPABILITY") {
+			return f[1:], nil
+		}
+	}
+	return nil, errors.New("no CAPABILITY response")
+}
+
+// startTLS sends STARTTLS
+func (p *imapProtocol) startTLS(m *mailConn) error {
+	_, err := p.command(m, "STARTTLS")
+	return err
+}
+
+// login sends LOGIN, unless the server has disabled it
+func (p *imapProtocol) login(m *mailConn, caps []string, user, password string) error {
+	if hasCapability(caps, "LOGINDISABLED") {
+		return errors.New("server has disabled LOGIN")
+	}
+	if strings.ContainsAny(user+password, "\r\n") {
+		return errors.New("user and password cannot contain line breaks")
+	}
+	_, err := p.command(m, fmt.Sprintf("LOGIN %s %s", imapQuote(user), imapQuote(password)))
+	return err
+}
+
+// quit sends LOGOUT
+func (p *imapProtocol) quit(m *mailConn) {
+	p.command(m, "LOGOUT")
+}
+
+// capabilityCommand names the capability phase
+func (*imapProtocol) capabilityCommand() string {
+	return "capability"
+}
+
+// imapQuote makes an IMAP quoted string
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,306 @@
+package checkers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Package checkers contains the parts shared by the mail server checks: each
+//reads the greeting, may ask for the server's capabilities, upgrade to TLS and
+//log in, and times every phase of the conversation
+
+// Mail connection security
+const (
+	// SecurityNone talks plain text
+	SecurityNone = "none"
+	// SecuritySTARTTLS upgrades the connection to TLS after the greeting
+	SecuritySTARTTLS = "starttls"
+	// SecurityTLS starts TLS straight away, as on ports 465, 993 and 995
+	SecurityTLS = "tls"
+)
+
+// maxMailLine is the longest response line read from a mail server
+const maxMailLine = 4096
+
+// mailSettings are the settings every mail server check has
+type mailSettings struct {
+	// Port to connect to
+	Port int `json:"port"`
+	// Timeout for the whole conversation in seconds
+	Timeout int `json:"timeout"`
+	// Security is none, starttls or tls
+	Security string `json:"security"`
+	tlsSettings
+	// Capabilities asks for the server's capabilities (EHLO, CAPABILITY or CAPA)
+	// after the greeting; STARTTLS and logging in ask for them regardless
+	Capabilities bool `json:"capabilities"`
+	// Expect is a regular expression the greeting must match
+	Expect string `json:"expect"`
+	// User and Password log in once the connection is secure; credentials are
+	// never sent in plain text. The password is stored encrypted
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// mailProtocol is the conversation of one kind of mail server
+type mailProtocol interface {
+	// greeting reads the greeting and returns it
+	greeting(m *mailConn) (string, error)
+	// capabilities asks the server what it supports
+	capabilities(m *mailConn) ([]string, error)
+	// startTLS asks the server to start TLS; the caller does the handshake
+	startTLS(m *mailConn) error
+	// login authenticates with the capabilities the server announced
+	login(m *mailConn, caps []string, user, password string) error
+	// quit ends the session politely
+	quit(m *mailConn)
+	// capabilityCommand names the capability phase, e.g. ehlo
+	capabilityCommand() string
+}
+
+// validate reports settings that can never work
+func (s mailSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	switch s.Security {
+	case SecurityNone, SecuritySTARTTLS, SecurityTLS:
+	default:
+		return fmt.Errorf("security must be none, starttls or tls, not %q", s.Security)
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	if s.User != "" && s.Security == SecurityNone {
+		return errors.New("logging in needs security set to starttls or tls; credentials are not sent in plain text")
+	}
+	return nil
+}
+
+// mailConn is a mail server connection that keeps the time of each phase
+type mailConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	mark   time.Time
+	phases []string
+	// metrics has the time of each phase as name_ms
+	metrics map[string]float64
+}
+
+// phase records the time since the previous phase ended as the named phase
+func (m *mailConn) phase(name string) {
+	d := time.Since(m.mark)
+	m.mark = time.Now()
+	m.phases = append(m.phases, fmt.Sprintf("%s %s", name, roundLatency(d)))
+	m.metrics[name+"_ms"] = float64(d) / float64(time.Millisecond)
+}
+
+// readLine reads one response line without its line ending
+func (m *mailConn) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, more, err := m.r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxMailLine {
+			return "", errors.New("response line too long")
+		}
+		if !more {
+			return string(line), nil
+		}
+	}
+}
+
+// writeLine sends a command followed by CRLF
+func (m *mailConn) writeLine(line string) error {
+	_, err := m.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// handshake starts TLS on the connection
+func (m *mailConn) handshake(ctx context.Context, cfg *tls.Config) (tls.ConnectionState, error) {
+	conn := tls.Client(m.conn, cfg)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	m.conn = conn
+	m.r = bufio.NewReader(conn)
+	return conn.ConnectionState(), nil
+}
+
+// checkMail runs the conversation common to mail servers
+func checkMail(ctx context.Context, t Target, s mailSettings, p mailProtocol) Result {
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	var password string
+	if s.User != "" {
+		var err error
+		password, err = secret(s.Password)
+		if err != nil {
+			return Result{Status: StatusUnknown, Message: err.Error(), Err: err}
+		}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	var verifyErr error
+	tlsConfig, err := s.config(s.hostServerName(t), &verifyErr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	m := &mailConn{mark: start, metrics: make(map[string]float64)}
+
+	//fail reports the phase that went wrong, with the time of those that did not
+	fail := func(what string, err error) Result {
+		msg := fmt.Sprintf("%s - %s: %s", addr, what, err)
+		if isTLSError(err) {
+			msg = fmt.Sprintf("%s - %s: %s", addr, what, describeTLSError(err))
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+			msg = fmt.Sprintf("%s - %s: timed out after %s", addr, what, timeout)
+		}
+		if len(m.phases) > 0 {
+			msg = fmt.Sprintf("%s (%s)", msg, strings.Join(m.phases, ", "))
+		}
+		return Result{Status: StatusProblem, Message: msg, Latency: time.Since(start), Err: err, Metrics: m.metrics}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	m.conn, err = dialer.DialContext(ctx, t.network("tcp"), addr)
+	if err != nil {
+		return fail("connection failed", err)
+	}
+	defer func() { m.conn.Close() }()
+	deadline, _ := ctx.Deadline()
+	m.conn.SetDeadline(deadline)
+	m.r = bufio.NewReader(m.conn)
+	m.phase("connect")
+
+	var details []string
+	if s.Security == SecurityTLS {
+		cs, err := m.handshake(ctx, tlsConfig)
+		if err != nil {
+			return fail("TLS handshake failed", err)
+		}
+		m.phase("tls")
+		details = append(details, tls.VersionName(cs.Version))
+	}
+
+	greeting, err := p.greeting(m)
+	if err != nil {
+		return fail("bad greeting", err)
+	}
+	m.phase("banner")
+	if s.Expect != "" && !regexp.MustCompile(s.Expect).MatchString(greeting) {
+		err := fmt.Errorf("%q does not match %s", truncate(greeting, 100), s.Expect)
+		return fail("unexpected greeting", err)
+	}
+
+	var caps []string
+	if s.Capabilities || s.Security == SecuritySTARTTLS || s.User != "" {
+		caps, err = p.capabilities(m)
+		if err != nil {
+			return fail(p.capabilityCommand()+" failed", err)
+		}
+		m.phase(p.capabilityCommand())
+	}
+
+	if s.Security == SecuritySTARTTLS {
+		if err := p.startTLS(m); err != nil {
+			return fail("STARTTLS refused", err)
+		}
+		cs, err := m.handshake(ctx, tlsConfig)
+		if err != nil {
+			return fail("STARTTLS failed", err)
+		}
+		m.phase("starttls")
+		details = append(details, "STARTTLS "+tls.VersionName(cs.Version))
+
+		//What was announced before TLS cannot be trusted, and often changes
+		if s.User != "" {
+			caps, err = p.capabilities(m)
+			if err != nil {
+				return fail(p.capabilityCommand()+" failed", err)
+			}
+		}
+	}
+
+	if s.User != "" {
+		if err := p.login(m, caps, s.User, password); err != nil {
+			return fail("login failed", err)
+		}
+		m.phase("auth")
+		details = append(details, "logged in as "+s.User)
+	}
+
+	p.quit(m)
+	latency := time.Since(start)
+
+	msg := fmt.Sprintf("%s - %s", addr, truncate(greeting, 100))
+	if len(details) > 0 {
+		msg = fmt.Sprintf("%s; %s", msg, strings.Join(details, ", "))
+	}
+	result := Result{
+		Status:  StatusHealthy,
+		Message: fmt.Sprintf("%s (%s)", msg, strings.Join(m.phases, ", ")),
+		Latency: latency,
+		Metrics: m.metrics,
+	}
+
+	//Secure, but only because verification was skipped
+	if verifyErr != nil {
+		result.Status = StatusWarning
+		result.Message += fmt.Sprintf(" (verification skipped: %s)", describeTLSError(verifyErr))
+		result.Err = verifyErr
+	}
+	return result
+}
+
+// hasCapability reports whether a capability list has name, ignoring case;
+// entries such as "AUTH PLAIN LOGIN" are matched on their first word
+func hasCapability(caps []string, name string) bool {
+	for _, c := range caps {
+		if f := strings.Fields(c); len(f) > 0 && strings.EqualFold(f[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityArgs returns the words after name in a capability list, such as
+// the mechanisms of AUTH PLAIN LOGIN
+func capabilityArgs(caps []string, name string) []string {
+	for _, c := range caps {
+		if f := strings.Fields(c); len(f) > 0 && strings.EqualFold(f[0], name) {
+			return f[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package checkers
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+// mailServer serves a line based mail protocol on 127.0.0.1 and returns the port
+// The server sends the greeting, then answers each line with reply; when reply
+// says so the connection is upgraded to TLS after the answer, and an answer
+// ending in a newline ends the session. With implicitTLS the server starts with
+// a TLS handshake, as on ports 465, 993 and 995
+func mailServer(t *testing.T, cert tls.Certificate, implicitTLS bool, greeting string, reply func(line string) (answer string, startTLS bool)) int {
+	t.Helper()
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	return serve(t, func(conn net.Conn) {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if implicitTLS {
+			conn = tls.Server(conn, cfg)
+		}
+		r := bufio.NewReader(conn)
+		fmt.Fprintf(conn, "%s\r\n", greeting)
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			answer, startTLS := reply(strings.TrimRight(line, "\r\n"))
+			fmt.Fprintf(conn, "%s\r\n", strings.TrimSuffix(answer, "\n"))
+			if strings.HasSuffix(answer, "\n") {
+				return
+			}
+			if startTLS {
+				conn = tls.Server(conn, cfg)
+				r = bufio.NewReader(conn)
+			}
+		}
+	})
+}
+
+// smtpReply answers like an SMTP server accepting AUTH PLAIN for monitor:hunter2
+func smtpReply(line string) (string, bool) {
+	cmd, arg, _ := strings.Cut(line, " ")
+	switch strings.ToUpper(cmd) {
+	case "EHLO":
+		return "250-mail.test greets " + arg + "\r\n250-STARTTLS\r\n250 AUTH PLAIN LOGIN", false
+	case "STARTTLS":
+		return "220 2.0.0 Ready to start TLS", true
+	case "AUTH":
+		token := strings.TrimPrefix(arg, "PLAIN ")
+		if b, _ := base64.StdEncoding.DecodeString(token); string(b) == "\x00monitor\x00hunter2" {
+			return "235 2.7.0 Authentication successful", false
+		}
+		return "535 5.7.8 Authentication credentials invalid", false
+	case "QUIT":
+		return "221 2.0.0 Bye\n", false
+	}
+	return "502 5.5.2 Error: command not recognized", false
+}
+
+// imapReply answers like an IMAP server accepting LOGIN for monitor:hunter2
+func imapReply(line string) (string, bool) {
+	tag, cmd, _ := strings.Cut(line, " ")
+	switch {
+	case cmd == "CAPABILITY":
+		return "* CAPABILITY IMAP4rev1 STARTTLS\r\n" + tag + " OK CAPABILITY completed", false
+	case cmd == "STARTTLS":
+		return tag + " OK Begin TLS negotiation now", true
+	case cmd == `LOGIN "monitor" "hunter2"`:
+		return tag + " OK Logged in", false
+	case strings.HasPrefix(cmd, "LOGIN "):
+		return tag + " NO [AUTHENTICATIONFAILED] Authentication failed", false
+	case cmd == "LOGOUT":
+		return "* BYE Logging out\r\n" + tag + " OK Logout completed\n", false
+	}
+	return tag + " BAD Unknown command", false
+}
+
+// pop3Reply answers like a POP3 server accepting USER monitor, PASS hunter2
+func pop3Reply(line string) (string, bool) {
+	switch line {
+	case "CAPA":
+		return "+OK\r\nUSER\r\nSTLS\r\n.", false
+	case "STLS":
+		return "+OK Begin TLS negotiation", true
+	case "USER monitor":
+		return "+OK", false
+	case "PASS hunter2":
+		return "+OK Logged in", false
+	case "QUIT":
+		return "+OK Bye\n", false
+	}
+	return "-ERR Authentication failed", false
+}
+
+func TestMailCheckers(t *testing.T) {
+	cert := testCert(t, time.Now().Add(24*time.Hour))
+	ca := caFile(t, cert.Leaf)
+
+	//The host has only an IP address, so certificates are verified against it
+	host := models.Host{IP: "127.0.0.1"}
+
+	servers := []struct {
+		service  string
+		greeting string
+		reply    func(string) (string, bool)
+	}{
+		{ServiceSMTP, "220 mail.test ESMTP", smtpReply},
+		{ServiceIMAP, "* OK IMAP4rev1 ready", imapReply},
+		{ServicePOP3, "+OK POP3 ready", pop3Reply},
+	}
+
+	//settings are formatted with the port and the CA file, which is empty for untrusted servers
+	tests := []struct {
+		name     string
+		implicit bool
+		ca       string
+		settings string
+		status   string
+		message  string
+	}{
+		{"plain", false, "", `{"port": %d, "ca_file": %q}`, StatusHealthy, "127.0.0.1:"},
+		{"expect", false, "", `{"port": %d, "ca_file": %q, "expect": "^nothing"}`, StatusProblem, "unexpected greeting"},
+		{"starttls login", false, ca, `{"port": %d, "ca_file": %q, "security": "starttls", "user": "monitor", "password": "hunter2"}`, StatusHealthy, "STARTTLS TLS 1.3, logged in as monitor"},
+		{"wrong password", false, ca, `{"port": %d, "ca_file": %q, "security": "starttls", "user": "monitor", "password": "guess"}`, StatusProblem, "login failed"},
+		{"untrusted", false, "", `{"port": %d, "ca_file": %q, "security": "starttls"}`, StatusProblem, "STARTTLS failed"},
+		{"skip verify", false, "", `{"port": %d, "ca_file": %q, "security": "starttls", "skip_verify": true}`, StatusWarning, "verification skipped"},
+		{"implicit tls", true, ca, `{"port": %d, "ca_file": %q, "security": "tls", "user": "monitor", "password": "hunter2"}`, StatusHealthy, "TLS 1.3, logged in as monitor"},
+		{"plain text login", false, "", `{"port": %d, "ca_file": %q, "user": "monitor", "password": "hunter2"}`, StatusProblem, "invalid settings"},
+	}
+
+	for _, srv := range servers {
+		for _, tt := range tests {
+			t.Run(srv.service+" "+tt.name, func(t *testing.T) {
+				port := mailServer(t, cert, tt.implicit, srv.greeting, srv.reply)
+				r := Run(target(srv.service, host, fmt.Sprintf(tt.settings, port, tt.ca)))
+				wantStatus(t, r, tt.status)
+				if !strings.Contains(r.Message, tt.message) {
+					t.Errorf("message %q does not contain %q", r.Message, tt.message)
+				}
+			})
+		}
+
+		t.Run(srv.service+" connection refused", func(t *testing.T) {
+			r := Run(target(srv.service, host, fmt.Sprintf(`{"port": %d}`, closedPort(t))))
+			wantStatus(t, r, StatusProblem)
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	caps := []string{"PIPELINING", "AUTH PLAIN LOGIN", "STARTTLS"}
+	if !hasCapability(caps, "auth") || hasCapability(caps, "PLAIN") {
+		t.Error("hasCapability should match the first word only, ignoring case")
+	}
+	if got := strings.Join(capabilityArgs(caps, "AUTH"), " "); got != "PLAIN LOGIN" {
+		t.Errorf("capabilityArgs = %q, want PLAIN LOGIN", got)
+	}
+	if got := capabilityArgs(caps, "SIZE"); got != nil {
+		t.Errorf("capabilityArgs = %v for a missing capability", got)
+	}
+}
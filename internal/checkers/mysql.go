@@ -0,0 +1,203 @@
+package checkers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ServiceMySQL is the name of the MySQL service in the services table
+const ServiceMySQL = "MySQL"
+
+func init() {
+	Register(ServiceMySQL, mysqlChecker{})
+}
+
+// mysqlSettings are the per-host-service settings for MySQL and MariaDB checks
+type mysqlSettings struct {
+	dbSettings
+	// Database to use; may be empty
+	Database string `json:"database"`
+	// TLS is false, preferred, true (verified) or skip-verify
+	TLS string `json:"tls"`
+}
+
+// mysqlChecker logs in to a MySQL or MariaDB server and runs a probe query
+type mysqlChecker struct{}
+
+// DefaultSettings returns the MySQL check defaults
+func (mysqlChecker) DefaultSettings() interface{} {
+	return &mysqlSettings{
+		dbSettings: dbSettings{Port: 3306, User: "root", Timeout: 10, Query: "SELECT 1"},
+		TLS:        "preferred",
+	}
+}
+
+// validate reports settings that can never work
+func (s mysqlSettings) validate() error {
+	switch s.TLS {
+	case "false", "preferred", "true", "skip-verify":
+	default:
+		return fmt.Errorf("tls must be false, preferred, true or skip-verify, not %q", s.TLS)
+	}
+	return s.dbSettings.validate()
+}
+
+// Check connects, runs the probe query and, when asked, measures replication lag
+func (c mysqlChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*mysqlSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	password, err := secret(s.Password)
+	if err != nil {
+		return Result{Status: StatusUnknown, Message: err.Error(), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	cfg := mysql.NewConfig()
+	cfg.User = s.User
+	cfg.Passwd = password
+	cfg.Net = t.network("tcp")
+	cfg.Addr = addr
+	cfg.DBName = s.Database
+	cfg.TLSConfig = s.TLS
+	cfg.Timeout = timeout
+	cfg.ReadTimeout = timeout
+	cfg.WriteTimeout = timeout
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	//One connection, so the probe and the lag query see the same server session
+	start := time.Now()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return dbFailure(addr, "connection failed", time.Since(start), err)
+	}
+	defer conn.Close()
+
+	var p probe
+	p.Value, p.Rows, err = firstValue(ctx, conn, s.Query)
+	latency := time.Since(start)
+	if err != nil {
+		return dbFailure(addr, "query failed", latency, err)
+	}
+
+	if s.checkLag() {
+		if err := mysqlLag(ctx, conn, &p); err != nil {
+			return dbFailure(addr, "cannot read replication lag", latency, err)
+		}
+	}
+
+	return s.result(addr, p, latency)
+}
+
+// firstValue runs a query and returns the first column of its first row as text
+func firstValue(ctx context.Context, conn *sql.Conn, query string) (string, bool, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", false, err
+	}
+	if !rows.Next() || len(cols) == 0 {
+		return "", false, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", false, err
+	}
+	if values[0] == nil {
+		return "NULL", true, nil
+	}
+	return string(values[0]), true, nil
+}
+
+// mysqlLag reads Seconds_Behind_Source from the replica status; servers older
+// than MySQL 8.0.22 and MariaDB only know the SLAVE spelling
+func mysqlLag(ctx context.Context, conn *sql.Conn, p *probe) error {
+	rows, err := conn.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = conn.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return err
+		}
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		//Not configured as a replica
+		return rows.Err()
+	}
+
+	values := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	status := make(map[string]sql.NullString, len(cols))
+	for i, col := range cols {
+		status[col] = values[i]
+	}
+
+	p.Replica = true
+	behind, ok := status["Seconds_Behind_Source"]
+	if !ok {
+		behind = status["Seconds_Behind_Master"]
+	}
+	if !behind.Valid {
+		p.Broken = "replication threads stopped"
+		for _, col := range []string{"Last_IO_Error", "Last_SQL_Error"} {
+			if e := status[col]; e.Valid && e.String != "" {
+				p.Broken = e.String
+				break
+			}
+		}
+		return nil
+	}
+
+	p.Lag, err = strconv.ParseFloat(behind.String, 64)
+	return err
+}
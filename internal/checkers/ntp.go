@@ -0,0 +1,207 @@
+package checkers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ServiceNTP is the name of the NTP service in the services table
+const ServiceNTP = "NTP"
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch
+const ntpEpochOffset = 2208988800
+
+func init() {
+	Register(ServiceNTP, ntpChecker{})
+}
+
+// ntpSettings are the per-host-service settings for NTP checks
+type ntpSettings struct {
+	// Port to query
+	Port int `json:"port"`
+	// Timeout is how long to wait for the reply, in seconds
+	Timeout int `json:"timeout"`
+	// OffsetWarning and OffsetProblem are clock offset thresholds in milliseconds,
+	// applied to the offset either side of our clock
+	OffsetWarning int `json:"offset_warning"`
+	OffsetProblem int `json:"offset_problem"`
+	// MaxStratum is the highest stratum accepted before warning; 16 means unsynchronized
+	MaxStratum int `json:"max_stratum"`
+}
+
+// ntpChecker queries an NTP server and compares its clock with ours
+type ntpChecker struct{}
+
+// DefaultSettings returns the NTP check defaults
+func (ntpChecker) DefaultSettings() interface{} {
+	return &ntpSettings{Port: 123, Timeout: 5, OffsetWarning: 100, OffsetProblem: 1000, MaxStratum: 15}
+}
+
+// validate reports settings that can never work
+func (s ntpSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if s.OffsetWarning < 0 || s.OffsetProblem < 0 {
+		return errors.New("offset thresholds cannot be negative")
+	}
+	if s.OffsetWarning > 0 && s.OffsetProblem > 0 && s.OffsetProblem < s.OffsetWarning {
+		return errors.New("offset_problem must not be lower than offset_warning")
+	}
+	return nil
+}
+
+// ntpReply is what an NTP server answered
+type ntpReply struct {
+	leap    int
+	stratum int
+	refID   [4]byte
+	offset  time.Duration
+	delay   time.Duration
+}
+
+// Check sends one client request and reports offset, stratum and round-trip delay
+func (c ntpChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*ntpSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	reply, err := queryNTP(ctx, t.network("udp"), addr)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - no reply within %ds", addr, s.Timeout), Err: err}
+		}
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", addr, err), Err: err}
+	}
+
+	result := Result{
+		Status:  StatusHealthy,
+		Latency: reply.delay,
+		Metrics: map[string]float64{
+			"offset_ms": float64(reply.offset) / float64(time.Millisecond),
+			"delay_ms":  float64(reply.delay) / float64(time.Millisecond),
+			"stratum":   float64(reply.stratum),
+		},
+	}
+	result.Message = fmt.Sprintf("%s - offset %s, stratum %d, delay %s", addr, roundLatency(reply.offset), reply.stratum, roundLatency(reply.delay))
+
+	//Stratum 0 is a kiss-o'-death, with the reason as the reference ID
+	if reply.stratum == 0 {
+		result.Status = StatusProblem
+		result.Message = fmt.Sprintf("%s - server refused the request (kiss code %s)", addr, string(reply.refID[:]))
+		result.Metrics = nil
+		return result
+	}
+	if reply.leap == 3 || reply.stratum >= 16 {
+		result.Status = StatusProblem
+		result.Message += ", server clock is not synchronized"
+		return result
+	}
+
+	abs := time.Duration(math.Abs(float64(reply.offset)))
+	switch {
+	case s.OffsetProblem > 0 && abs > time.Duration(s.OffsetProblem)*time.Millisecond:
+		result.Status = StatusProblem
+		result.Message += fmt.Sprintf(", offset over %dms", s.OffsetProblem)
+	case s.OffsetWarning > 0 && abs > time.Duration(s.OffsetWarning)*time.Millisecond:
+		result.Status = StatusWarning
+		result.Message += fmt.Sprintf(", offset over %dms", s.OffsetWarning)
+	}
+
+	if s.MaxStratum > 0 && reply.stratum > s.MaxStratum {
+		result.Status = worstStatus(result.Status, StatusWarning)
+		result.Message += fmt.Sprintf(", stratum over %d", s.MaxStratum)
+	}
+	return result
+}
+
+// queryNTP sends an NTPv4 client request and works out offset and delay from the reply
+func queryNTP(ctx context.Context, network, addr string) (ntpReply, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return ntpReply{}, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	//LI 0, version 4, mode 3 (client); the transmit timestamp is random so a
+	//reply can be matched to this request without revealing our clock
+	req := make([]byte, 48)
+	req[0] = 0<<6 | 4<<3 | 3
+	if _, err := rand.Read(req[40:48]); err != nil {
+		return ntpReply{}, err
+	}
+
+	t1 := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return ntpReply{}, err
+	}
+
+	buf := make([]byte, 512)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return ntpReply{}, err
+		}
+		t4 := time.Now()
+
+		resp := buf[:n]
+		if n < 48 || resp[0]&0x7 != 4 || string(resp[24:32]) != string(req[40:48]) {
+			//Not a server reply to this request
+			continue
+		}
+
+		var reply ntpReply
+		reply.leap = int(resp[0] >> 6)
+		reply.stratum = int(resp[1])
+		copy(reply.refID[:], resp[12:16])
+
+		t2 := ntpTime(resp[32:40])
+		t3 := ntpTime(resp[40:48])
+		reply.offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+		reply.delay = t4.Sub(t1) - t3.Sub(t2)
+		if reply.delay < 0 {
+			reply.delay = 0
+		}
+		return reply, nil
+	}
+}
+
+// ntpTime converts a 64 bit NTP timestamp to a time
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(secs, frac*1e9>>32)
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,73 @@
+package checkers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+// ntpServer answers NTP client requests on 127.0.0.1 with a clock that is
+// skew ahead of ours, and returns the port
+func ntpServer(t *testing.T, leap, stratum int, refID string, skew time.Duration) int {
+	return udpServer(t, func(req []byte) []byte {
+		if len(req) < 48 {
+			return nil
+		}
+		now := time.Now().Add(skew)
+
+		resp := make([]byte, 48)
+		resp[0] = byte(leap<<6 | 4<<3 | 4)
+		resp[1] = byte(stratum)
+		copy(resp[12:16], refID)
+		copy(resp[24:32], req[40:48])
+		putNTPTime(resp[32:40], now)
+		putNTPTime(resp[40:48], now)
+		return resp
+	})
+}
+
+// putNTPTime writes a time as a 64 bit NTP timestamp
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/1e9))
+}
+
+func TestNTPTime(t *testing.T) {
+	want := time.Date(2026, 10, 18, 12, 30, 0, 250000000, time.UTC)
+	b := make([]byte, 8)
+	putNTPTime(b, want)
+	if got := ntpTime(b); got.Sub(want).Abs() > time.Microsecond {
+		t.Errorf("got %s, want %s", got.UTC(), want)
+	}
+}
+
+func TestNTPChecker(t *testing.T) {
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name    string
+		port    int
+		status  string
+		message string
+	}{
+		{"in sync", ntpServer(t, 0, 2, "GPS\x00", 0), StatusHealthy, "stratum 2"},
+		{"offset warning", ntpServer(t, 0, 2, "GPS\x00", 500*time.Millisecond), StatusWarning, "offset over 100ms"},
+		{"offset problem", ntpServer(t, 0, 2, "GPS\x00", -5*time.Second), StatusProblem, "offset over 1000ms"},
+		{"high stratum", ntpServer(t, 0, 15, "\x7f\x00\x00\x01", 0), StatusWarning, "stratum over 14"},
+		{"unsynchronized", ntpServer(t, 3, 16, "INIT", 0), StatusProblem, "not synchronized"},
+		{"kiss of death", ntpServer(t, 3, 0, "RATE", 0), StatusProblem, "kiss code RATE"},
+		{"no reply", udpServer(t, func([]byte) []byte { return nil }), StatusProblem, "no reply within 1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceNTP, host, fmt.Sprintf(`{"port": %d, "timeout": 1, "max_stratum": 14}`, tt.port)))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ServicePing is the name of the ping service in the services table
+const ServicePing = "Ping"
+
+// ICMP protocol numbers, used to parse replies
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// pingRuns counts ping runs, giving each its own ICMP identifier
+var pingRuns atomic.Uint32
+
+func init() {
+	Register(ServicePing, pingChecker{})
+}
+
+// pingSettings are the per-host-service settings for ping checks
+type pingSettings struct {
+	// Count is the number of echo requests sent to each address
+	Count int `json:"count"`
+	// IntervalMS is the time between echo requests in milliseconds
+	IntervalMS int `json:"interval_ms"`
+	// Timeout is how long to wait for each reply, in seconds
+	Timeout int `json:"timeout"`
+	// Privileged uses raw ICMP sockets, which need root or CAP_NET_RAW, instead
+	// of unprivileged datagram sockets (Linux: net.ipv4.ping_group_range)
+	Privileged bool `json:"privileged"`
+	// LossWarning and LossProblem are packet loss percentages; 100% loss is always a problem
+	LossWarning float64 `json:"loss_warning"`
+	LossProblem float64 `json:"loss_problem"`
+	// RTTWarningMS and RTTProblemMS are average round trip times; 0 is not checked
+	RTTWarningMS int `json:"rtt_warning_ms"`
+	RTTProblemMS int `json:"rtt_problem_ms"`
+}
+
+// pingStats are the results of pinging one address
+type pingStats struct {
+	Addr     net.IP
+	Sent     int
+	Received int
+	Min      time.Duration
+	Max      time.Duration
+	Total    time.Duration
+}
+
+// Loss returns the percentage of echo requests without a reply
+func (p pingStats) Loss() float64 {
+	if p.Sent == 0 {
+		return 0
+	}
+	return float64(p.Sent-p.Received) / float64(p.Sent) * 100
+}
+
+// Avg returns the average round trip time
+func (p pingStats) Avg() time.Duration {
+	if p.Received == 0 {
+		return 0
+	}
+	return p.Total / time.Duration(p.Received)
+}
+
+// family returns ipv4 or ipv6, used to name metrics
+func (p pingStats) family() string {
+	if p.Addr.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// String describes the stats like the summary line of ping
+func (p pingStats) String() string {
+	s := fmt.Sprintf("%s: %d sent, %d received, %.0f%% loss", p.Addr, p.Sent, p.Received, p.Loss())
+	if p.Received > 0 {
+		s += fmt.Sprintf(", rtt min/avg/max %s/%s/%s", roundLatency(p.Min), roundLatency(p.Avg()), roundLatency(p.Max))
+	}
+	return s
+}
+
+// pingChecker sends ICMP echo requests to the host's addresses
+type pingChecker struct{}
+
+// DefaultSettings returns the ping check defaults
+func (pingChecker) DefaultSettings() interface{} {
+	return &pingSettings{Count: 4, IntervalMS: 500, Timeout: 2, LossWarning: 20, LossProblem: 60}
+}
+
+// validate reports settings that can never work
+func (s pingSettings) validate() error {
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if s.Count < 1 {
+		return errors.New("count must be at least one")
+	}
+	if s.IntervalMS < 0 {
+		return errors.New("interval_ms cannot be negative")
+	}
+	if s.LossWarning < 0 || s.LossWarning > 100 || s.LossProblem < 0 || s.LossProblem > 100 {
+		return errors.New("loss_warning and loss_problem must be percentages between 0 and 100")
+	}
+	if s.RTTWarningMS < 0 || s.RTTProblemMS < 0 {
+		return errors.New("rtt_warning_ms and rtt_problem_ms cannot be negative")
+	}
+	//Thresholds of 0 are not checked, so only compare those that are set
+	if s.LossWarning > 0 && s.LossProblem > 0 && s.LossProblem < s.LossWarning {
+		return errors.New("loss_problem cannot be less than loss_warning")
+	}
+	if s.RTTWarningMS > 0 && s.RTTProblemMS > 0 && s.RTTProblemMS < s.RTTWarningMS {
+		return errors.New("rtt_problem_ms cannot be less than rtt_warning_ms")
+	}
+	return nil
+}
+
+// duration is the longest a ping check can take: every request waiting its
+// full timeout, for an IPv4 and an IPv6 address in turn
+func (s pingSettings) duration() time.Duration {
+	perAddress := time.Duration(max(s.Count, 1)) * (time.Duration(s.IntervalMS)*time.Millisecond + time.Duration(s.Timeout)*time.Second)
+	return 2 * perAddress
+}
+
+// Check pings the host's IPv4 and IPv6 addresses and reports loss and round trip times
+func (c pingChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*pingSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	addrs, err := pingAddrs(ctx, t)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+
+	result := Result{Status: StatusHealthy, Metrics: make(map[string]float64)}
+	var messages []string
+
+	for _, addr := range addrs {
+		stats, err := s.ping(ctx, addr)
+		if err != nil {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s: %s", addr, err), Err: err}
+		}
+
+		status := s.status(stats)
+		result.Status = worstStatus(result.Status, status)
+		messages = append(messages, stats.String())
+
+		prefix := stats.family() + "_"
+		result.Metrics[prefix+"packets_sent"] = float64(stats.Sent)
+		result.Metrics[prefix+"packets_received"] = float64(stats.Received)
+		result.Metrics[prefix+"packet_loss"] = stats.Loss()
+		if stats.Received > 0 {
+			result.Metrics[prefix+"rtt_min_ms"] = float64(stats.Min) / float64(time.Millisecond)
+			result.Metrics[prefix+"rtt_avg_ms"] = float64(stats.Avg()) / float64(time.Millisecond)
+			result.Metrics[prefix+"rtt_max_ms"] = float64(stats.Max) / float64(time.Millisecond)
+		}
+
+		//Report the slowest address as the latency, so response time thresholds see it
+		if stats.Avg() > result.Latency {
+			result.Latency = stats.Avg()
+		}
+	}
+
+	result.Message = strings.Join(messages, "; ")
+	return result
+}
+
+// status grades the stats for one address against the loss and RTT thresholds
+func (s pingSettings) status(p pingStats) string {
+	loss := p.Loss()
+	avg := p.Avg()
+	switch {
+	case p.Received == 0:
+		return StatusProblem
+	case s.LossProblem > 0 && loss >= s.LossProblem:
+		return StatusProblem
+	case s.RTTProblemMS > 0 && avg > time.Duration(s.RTTProblemMS)*time.Millisecond:
+		return StatusProblem
+	case s.LossWarning > 0 && loss >= s.LossWarning:
+		return StatusWarning
+	case s.RTTWarningMS > 0 && avg > time.Duration(s.RTTWarningMS)*time.Millisecond:
+		return StatusWarning
+	}
+	return StatusHealthy
+}
+
+// ping sends Count echo requests to addr, one at a time, and collects the replies
+func (s pingSettings) ping(ctx context.Context, addr net.IP) (pingStats, error) {
+	stats := pingStats{Addr: addr}
+
+	isV4 := addr.To4() != nil
+	network, listen := "udp4", "0.0.0.0"
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	proto := protocolICMP
+	if !isV4 {
+		network, listen = "udp6", "::"
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		proto = protocolIPv6ICMP
+	}
+	if s.Privileged {
+		if isV4 {
+			network = "ip4:icmp"
+		} else {
+			network = "ip6:ipv6-icmp"
+		}
+	}
+
+	conn, err := icmp.ListenPacket(network, listen)
+	if err != nil {
+		if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+			return stats, fmt.Errorf("not permitted to open an ICMP socket (%s); allow this user's group in net.ipv4.ping_group_range or set privileged and run with CAP_NET_RAW", err)
+		}
+		return stats, err
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.UDPAddr{IP: addr}
+	if s.Privileged {
+		dst = &net.IPAddr{IP: addr}
+	}
+
+	//Unprivileged sockets get their id from the kernel, which also filters replies;
+	//raw sockets see every reply, so match on our own id too. Each run takes its
+	//own id, as concurrent checks would otherwise count each other's replies
+	id := (os.Getpid() + int(pingRuns.Add(1))) & 0xffff
+	timeout := time.Duration(s.Timeout) * time.Second
+	interval := time.Duration(s.IntervalMS) * time.Millisecond
+	buf := make([]byte, 1500)
+
+	for seq := 1; seq <= s.Count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+				return stats, nil
+			case <-time.After(interval):
+			}
+		}
+
+		msg := icmp.Message{
+			Type: echoType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("vigilate-ping")},
+		}
+		b, err := msg.Marshal(nil)
+		if err != nil {
+			return stats, err
+		}
+
+		start := time.Now()
+		if _, err := conn.WriteTo(b, dst); err != nil {
+			return stats, err
+		}
+		stats.Sent++
+
+		deadline := start.Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		_ = conn.SetReadDeadline(deadline)
+
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				//timed out waiting for this reply; count it as lost
+				break
+			}
+			reply, err := icmp.ParseMessage(proto, buf[:n])
+			if err != nil || reply.Type != replyType {
+				continue
+			}
+			echo, ok := reply.Body.(*icmp.Echo)
+			if !ok || echo.Seq != seq || (s.Privileged && echo.ID != id) {
+				continue
+			}
+
+			rtt := time.Since(start)
+			stats.Received++
+			stats.Total += rtt
+			if stats.Min == 0 || rtt < stats.Min {
+				stats.Min = rtt
+			}
+			if rtt > stats.Max {
+				stats.Max = rtt
+			}
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// pingAddrs returns the host's IPv4 and IPv6 addresses in the target's family,
+// resolving the host name when none is set
+func pingAddrs(ctx context.Context, t Target) ([]net.IP, error) {
+	var addrs []net.IP
+	for _, a := range []string{t.Host.IP, t.Host.IPV6} {
+		if a == "" {
+			continue
+		}
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IP address", a)
+		}
+		if t.allows(ip) {
+			addrs = append(addrs, ip)
+		}
+	}
+	if len(addrs) > 0 {
+		return addrs, nil
+	}
+
+	name := t.Host.CanonicalName
+	if name == "" {
+		name = t.Host.HostName
+	}
+	if name == "" {
+		return nil, errors.New("host has no IP address or name")
+	}
+
+	resolved, err := net.DefaultResolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", name, err)
+	}
+
+	//One address from each family is enough
+	var v4, v6 net.IP
+	for _, r := range resolved {
+		if !t.allows(r.IP) {
+			continue
+		}
+		if r.IP.To4() != nil && v4 == nil {
+			v4 = r.IP
+		} else if r.IP.To4() == nil && v6 == nil {
+			v6 = r.IP
+		}
+	}
+	for _, ip := range []net.IP{v4, v6} {
+		if ip != nil {
+			addrs = append(addrs, ip)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s has no %s address", name, familyName(t.Family))
+	}
+	return addrs, nil
+}
+
+// worstStatus returns the more severe of two statuses
+func worstStatus(a, b string) string {
+	rank := map[string]int{StatusPending: 0, StatusHealthy: 1, StatusWarning: 2, StatusUnknown: 3, StatusProblem: 4}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,104 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestPingStatus(t *testing.T) {
+	s := pingSettings{LossWarning: 20, LossProblem: 60, RTTWarningMS: 100, RTTProblemMS: 500}
+
+	tests := []struct {
+		name     string
+		sent     int
+		received int
+		avg      time.Duration
+		want     string
+	}{
+		{"all replies", 4, 4, time.Millisecond, StatusHealthy},
+		{"no replies", 4, 0, 0, StatusProblem},
+		{"some loss", 4, 3, time.Millisecond, StatusWarning},
+		{"heavy loss", 5, 2, time.Millisecond, StatusProblem},
+		{"slow", 4, 4, 200 * time.Millisecond, StatusWarning},
+		{"too slow", 4, 4, time.Second, StatusProblem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pingStats{Sent: tt.sent, Received: tt.received, Total: tt.avg * time.Duration(tt.received)}
+			if got := s.status(p); got != tt.want {
+				t.Errorf("got %s (loss %.0f%%, avg %s), want %s", got, p.Loss(), p.Avg(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPingSettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings string
+		valid    bool
+	}{
+		{`{"count": 4, "loss_warning": 20, "loss_problem": 60}`, true},
+		{`{"rtt_warning_ms": 100, "rtt_problem_ms": 500}`, true},
+		{`{"loss_warning": 0, "loss_problem": 10}`, true},
+		{`{"rtt_warning_ms": 100}`, true},
+		{`{"count": 0}`, false},
+		{`{"count": -3}`, false},
+		{`{"timeout": 0}`, false},
+		{`{"interval_ms": -1}`, false},
+		{`{"loss_warning": -5}`, false},
+		{`{"loss_problem": 150}`, false},
+		{`{"loss_warning": 60, "loss_problem": 20}`, false},
+		{`{"rtt_warning_ms": -1}`, false},
+		{`{"rtt_warning_ms": 500, "rtt_problem_ms": 100}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(ServicePing, tt.settings)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, want valid %v", tt.settings, err, tt.valid)
+		}
+	}
+}
+
+func TestPingAddrs(t *testing.T) {
+	host := models.Host{IP: "192.0.2.1", IPV6: "2001:db8::1"}
+
+	tests := []struct {
+		family string
+		want   string
+	}{
+		{"", "192.0.2.1 2001:db8::1"},
+		{FamilyIPv4, "192.0.2.1"},
+		{FamilyIPv6, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		addrs, err := pingAddrs(context.Background(), Target{Host: host, Family: tt.family})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Trim(fmt.Sprint(addrs), "[]"); got != tt.want {
+			t.Errorf("family %q: got %s, want %s", tt.family, got, tt.want)
+		}
+	}
+
+	if _, err := pingAddrs(context.Background(), Target{Host: models.Host{IP: "web1"}}); err == nil {
+		t.Error("no error for an IP address that does not parse")
+	}
+}
+
+func TestPingChecker(t *testing.T) {
+	s := pingSettings{Count: 2, IntervalMS: 10, Timeout: 1}
+	if _, err := s.ping(context.Background(), net.ParseIP("127.0.0.1")); err != nil && strings.Contains(err.Error(), "not permitted") {
+		t.Skip(err)
+	}
+
+	r := Run(target(ServicePing, models.Host{IP: "127.0.0.1"}, `{"count": 2, "interval_ms": 10}`))
+	wantStatus(t, r, StatusHealthy)
+	if r.Metrics["ipv4_packets_received"] != 2 {
+		t.Errorf("metrics %v, want 2 packets received", r.Metrics)
+	}
+}
@@ -0,0 +1,110 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ServicePOP3 is the name of the POP3 service in the services table
+const ServicePOP3 = "POP3"
+
+func init() {
+	Register(ServicePOP3, pop3Checker{})
+}
+
+// pop3Checker talks to a POP3 server: greeting, CAPA, STLS and USER/PASS
+type pop3Checker struct{}
+
+// DefaultSettings returns the POP3 check defaults
+func (pop3Checker) DefaultSettings() interface{} {
+	return &mailSettings{Port: 110, Timeout: 20, Security: SecurityNone, Capabilities: true}
+}
+
+// Check holds a POP3 conversation up to logging in
+func (c pop3Checker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*mailSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	return checkMail(ctx, t, *s, pop3Protocol{})
+}
+
+// pop3Protocol is the POP3 conversation
+type pop3Protocol struct{}
+
+// status reads a +OK or -ERR status line, returning the text after +OK
+func (pop3Protocol) status(m *mailConn) (string, error) {
+	line, err := m.readLine()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return "", errors.New(truncate(line, 200))
+	}
+	return strings.TrimSpace(line[3:]), nil
+}
+
+// command sends a command and reads its status
+func (p pop3Protocol) command(m *mailConn, cmd string) error {
+	if err := m.writeLine(cmd); err != nil {
+		return err
+	}
+	_, err := p.status(m)
+	return err
+}
+
+// greeting reads the +OK greeting
+func (p pop3Protocol) greeting(m *mailConn) (string, error) {
+	text, err := p.status(m)
+	if err != nil {
+		return "", err
+	}
+	return "+OK " + text, nil
+}
+
+// capabilities sends CAPA and returns the lines of its multi-line response
+func (p pop3Protocol) capabilities(m *mailConn) ([]string, error) {
+	if err := p.command(m, "CAPA"); err != nil {
+		return nil, err
+	}
+
+	var caps []string
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "." {
+			return caps, nil
+		}
+		caps = append(caps, strings.TrimPrefix(line, "."))
+	}
+}
+
+// startTLS sends STLS
+func (p pop3Protocol) startTLS(m *mailConn) error {
+	return p.command(m, "STLS")
+}
+
+// login sends USER and PASS
+func (p pop3Protocol) login(m *mailConn, caps []string, user, password string) error {
+	if strings.ContainsAny(user+password, "\r\n") {
+		return errors.New("user and password cannot contain line breaks")
+	}
+	if err := p.command(m, "USER "+user); err != nil {
+		return err
+	}
+	return p.command(m, "PASS "+password)
+}
+
+// quit sends QUIT
+func (p pop3Protocol) quit(m *mailConn) {
+	p.command(m, "QUIT")
+}
+
+// capabilityCommand names the capability phase
+func (pop3Protocol) capabilityCommand() string {
+	return "capa"
+}
@@ -0,0 +1,133 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ServicePostgres is the name of the PostgreSQL service in the services table
+const ServicePostgres = "PostgreSQL"
+
+// postgresLagQuery returns whether the server is a replica and how far its
+// replay is behind; a replica that has replayed everything it received is not behind
+const postgresLagQuery = `SELECT pg_is_in_recovery(),
+	CASE WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END::float8`
+
+func init() {
+	Register(ServicePostgres, postgresChecker{})
+}
+
+// postgresSettings are the per-host-service settings for PostgreSQL checks
+type postgresSettings struct {
+	dbSettings
+	// Database to connect to
+	Database string `json:"database"`
+	// SSLMode is a libpq sslmode: disable, prefer, require, verify-ca or verify-full
+	SSLMode string `json:"sslmode"`
+}
+
+// postgresChecker logs in to a PostgreSQL server and runs a probe query
+type postgresChecker struct{}
+
+// DefaultSettings returns the PostgreSQL check defaults
+func (postgresChecker) DefaultSettings() interface{} {
+	return &postgresSettings{
+		dbSettings: dbSettings{Port: 5432, User: "postgres", Timeout: 10, Query: "SELECT 1"},
+		Database:   "postgres",
+		SSLMode:    "prefer",
+	}
+}
+
+// validate reports settings that can never work
+func (s postgresSettings) validate() error {
+	switch s.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("unknown sslmode %q", s.SSLMode)
+	}
+	return s.dbSettings.validate()
+}
+
+// Check connects, runs the probe query and, when asked, measures replication lag
+func (c postgresChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*postgresSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	password, err := secret(s.Password)
+	if err != nil {
+		return Result{Status: StatusUnknown, Message: err.Error(), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	//A URL keeps passwords with spaces and quotes intact
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(s.User, password),
+		Host:     addr,
+		Path:     "/" + s.Database,
+		RawQuery: url.Values{"sslmode": {s.SSLMode}, "connect_timeout": {strconv.Itoa(s.Timeout)}}.Encode(),
+	}
+	cfg, err := pgx.ParseConfig(dsn.String())
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	cfg.DialFunc = t.dialContext(&net.Dialer{KeepAlive: 5 * time.Minute})
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return dbFailure(addr, "connection failed", time.Since(start), err)
+	}
+	defer conn.Close(context.Background())
+
+	var p probe
+	rows, err := conn.Query(ctx, s.Query, pgx.QueryResultFormats{pgx.TextFormatCode})
+	if err != nil {
+		return dbFailure(addr, "query failed", time.Since(start), err)
+	}
+	if rows.Next() {
+		p.Rows = true
+		if raw := rows.RawValues(); len(raw) > 0 {
+			p.Value = "NULL"
+			if raw[0] != nil {
+				p.Value = string(raw[0])
+			}
+		}
+	}
+	rows.Close()
+	latency := time.Since(start)
+	if err := rows.Err(); err != nil {
+		return dbFailure(addr, "query failed", latency, err)
+	}
+
+	if s.checkLag() {
+		err = conn.QueryRow(ctx, postgresLagQuery).Scan(&p.Replica, &p.Lag)
+		if err != nil {
+			return dbFailure(addr, "cannot read replication lag", latency, err)
+		}
+	}
+
+	return s.result(addr, p, latency)
+}
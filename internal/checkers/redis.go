@@ -0,0 +1,230 @@
+package checkers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceRedis is the name of the Redis service in the services table
+const ServiceRedis = "Redis"
+
+// maxRedisReply is the largest bulk reply read from a Redis server
+const maxRedisReply = 1 << 20
+
+func init() {
+	Register(ServiceRedis, redisChecker{})
+}
+
+// redisSettings are the per-host-service settings for Redis checks
+type redisSettings struct {
+	dbSettings
+	// Database is the logical database number to SELECT
+	Database int `json:"database"`
+	// TLS connects over TLS, verifying the certificate against the host name
+	TLS bool `json:"tls"`
+}
+
+// redisChecker logs in to a Redis server and sends a probe command
+// The user setting is only sent with a password, for Redis 6 ACLs
+type redisChecker struct{}
+
+// DefaultSettings returns the Redis check defaults
+func (redisChecker) DefaultSettings() interface{} {
+	return &redisSettings{dbSettings: dbSettings{Port: 6379, Timeout: 10, Query: "PING"}}
+}
+
+// validate reports settings that can never work
+func (s redisSettings) validate() error {
+	if s.Database < 0 {
+		return errors.New("database cannot be negative")
+	}
+	return s.dbSettings.validate()
+}
+
+// Check connects, sends the probe command and, when asked, measures replication lag
+// The lag of a Redis replica is how long ago it last heard from its master
+func (c redisChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*redisSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	password, err := secret(s.Password)
+	if err != nil {
+		return Result{Status: StatusUnknown, Message: err.Error(), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: timeout}
+	if s.TLS {
+		serverName := t.Host.HostName
+		if net.ParseIP(serverName) != nil {
+			serverName = host
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: serverName}}).DialContext(ctx, t.network("tcp"), addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, t.network("tcp"), addr)
+	}
+	if err != nil {
+		return dbFailure(addr, "connection failed", time.Since(start), err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	rc := redisConn{w: conn, r: bufio.NewReader(conn)}
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if s.User != "" {
+			args = []string{"AUTH", s.User, password}
+		}
+		if _, err := rc.do(args...); err != nil {
+			return dbFailure(addr, "authentication failed", time.Since(start), err)
+		}
+	}
+	if s.Database > 0 {
+		if _, err := rc.do("SELECT", strconv.Itoa(s.Database)); err != nil {
+			return dbFailure(addr, "cannot select database", time.Since(start), err)
+		}
+	}
+
+	var p probe
+	p.Value, err = rc.do(strings.Fields(s.Query)...)
+	latency := time.Since(start)
+	if err != nil {
+		return dbFailure(addr, "command failed", latency, err)
+	}
+	p.Rows = true
+
+	if s.checkLag() {
+		info, err := rc.do("INFO", "replication")
+		if err != nil {
+			return dbFailure(addr, "cannot read replication lag", latency, err)
+		}
+		redisLag(info, &p)
+	}
+
+	return s.result(addr, p, latency)
+}
+
+// redisLag reads the replica state from the INFO replication section
+func redisLag(info string, p *probe) {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), ":"); ok {
+			fields[k] = v
+		}
+	}
+
+	if fields["role"] != "slave" {
+		return
+	}
+	p.Replica = true
+	if link := fields["master_link_status"]; link != "up" {
+		p.Broken = fmt.Sprintf("link to master is %s", link)
+		return
+	}
+	p.Lag, _ = strconv.ParseFloat(fields["master_last_io_seconds_ago"], 64)
+}
+
+// redisConn speaks enough of the Redis protocol (RESP) to run single commands
+type redisConn struct {
+	w io.Writer
+	r *bufio.Reader
+}
+
+// do sends a command and returns its reply as text; for arrays, the first element
+func (c redisConn) do(args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("no command")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.w, b.String()); err != nil {
+		return "", err
+	}
+	return c.reply()
+}
+
+// reply reads one reply; error replies are returned as errors
+func (c redisConn) reply() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("bad reply %q", line)
+		}
+		if n < 0 {
+			return "NULL", nil
+		}
+		if n > maxRedisReply {
+			return "", fmt.Errorf("reply of %d bytes is too large", n)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("bad reply %q", line)
+		}
+		if n <= 0 {
+			return "", nil
+		}
+		first, err := c.reply()
+		if err != nil {
+			return "", err
+		}
+		for i := 1; i < n; i++ {
+			if _, err := c.reply(); err != nil {
+				return "", err
+			}
+		}
+		return first, nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", truncate(line, 40))
+}
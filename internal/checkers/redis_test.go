@@ -0,0 +1,96 @@
+package checkers
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+)
+
+// fakeRedis serves enough of RESP for the checker: AUTH with the password
+// hunter2, SELECT, PING, GET and INFO replication, as the given replication section
+func fakeRedis(t *testing.T, replication string) int {
+	return serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readCommand(r)
+			if err != nil {
+				return
+			}
+			switch strings.ToUpper(args[0]) {
+			case "AUTH":
+				if args[len(args)-1] != "hunter2" {
+					fmt.Fprint(conn, "-WRONGPASS invalid username-password pair\r\n")
+					continue
+				}
+				fmt.Fprint(conn, "+OK\r\n")
+			case "SELECT":
+				fmt.Fprint(conn, "+OK\r\n")
+			case "PING":
+				fmt.Fprint(conn, "+PONG\r\n")
+			case "GET":
+				fmt.Fprint(conn, "$-1\r\n")
+			case "INFO":
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(replication), replication)
+			default:
+				fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+			}
+		}
+	})
+}
+
+// readCommand reads one command sent as a RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	rc := redisConn{r: r}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad command %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if args[i], err = rc.reply(); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func TestRedisChecker(t *testing.T) {
+	master := fakeRedis(t, "# Replication\r\nrole:master\r\nconnected_slaves:1\r\n")
+	replica := fakeRedis(t, "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:12\r\n")
+	broken := fakeRedis(t, "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\n")
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"ping", fmt.Sprintf(`{"port": %d}`, master), StatusHealthy, "PING returned PONG"},
+		{"auth and select", fmt.Sprintf(`{"port": %d, "password": "hunter2", "database": 2, "expect": "^PONG$"}`, master), StatusHealthy, "PONG"},
+		{"wrong password", fmt.Sprintf(`{"port": %d, "password": "guess"}`, master), StatusProblem, "authentication failed: WRONGPASS"},
+		{"null reply", fmt.Sprintf(`{"port": %d, "query": "GET missing", "expect": "^ok$"}`, master), StatusProblem, "expected a match"},
+		{"unknown command", fmt.Sprintf(`{"port": %d, "query": "FLY"}`, master), StatusProblem, "command failed: ERR unknown command"},
+		{"not a replica", fmt.Sprintf(`{"port": %d, "lag_warning": 10}`, master), StatusWarning, "not a replica"},
+		{"lag", fmt.Sprintf(`{"port": %d, "lag_warning": 10, "lag_problem": 60}`, replica), StatusWarning, "replication lag 12s over 10s"},
+		{"link down", fmt.Sprintf(`{"port": %d, "lag_problem": 60}`, broken), StatusProblem, "link to master is down"},
+		{"connection refused", fmt.Sprintf(`{"port": %d}`, closedPort(t)), StatusProblem, "connection failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceRedis, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
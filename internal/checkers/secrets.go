@@ -0,0 +1,133 @@
+package checkers
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks a settings value that is stored encrypted
+const sealedPrefix = "enc:v1:"
+
+// secretKeys are the settings keys whose values are stored encrypted; only
+// passwords are, so headers and bodies are stored as they are entered
+var secretKeys = map[string]bool{
+	"password": true,
+}
+
+// SecretKey is the passphrase the passwords in settings are encrypted with;
+// settings with a password cannot be saved or used while it is empty
+var SecretKey string
+
+// errNoSecretKey is returned when credentials are saved or read without a key
+var errNoSecretKey = errors.New("credentials cannot be stored; start vigilate with -secretKey set")
+
+// SealSettings encrypts the credentials in raw settings, leaving values that
+// are already encrypted as they are, and returns the settings to store
+func SealSettings(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return raw, nil
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+		return "", err
+	}
+
+	changed := false
+	for k, v := range settings {
+		if !secretKeys[k] {
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(v, &value); err != nil {
+			return "", fmt.Errorf("%s must be a string", k)
+		}
+		if value == "" || strings.HasPrefix(value, sealedPrefix) {
+			continue
+		}
+
+		sealed, err := seal(value)
+		if err != nil {
+			return "", err
+		}
+		settings[k], _ = json.Marshal(sealed)
+		changed = true
+	}
+
+	if !changed {
+		return raw, nil
+	}
+
+	out, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
+// secret returns the plain text of a credential from the settings; values
+// saved before they were sealed are used as they are
+func secret(value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	return open(value)
+}
+
+// cipherBlock returns the AES-GCM cipher for SecretKey
+func cipherBlock() (cipher.AEAD, error) {
+	if SecretKey == "" {
+		return nil, errNoSecretKey
+	}
+	key := sha256.Sum256([]byte(SecretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts a value as enc:v1: followed by the base64 nonce and ciphertext
+func seal(value string) (string, error) {
+	aead, err := cipherBlock()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := aead.Seal(nonce, nonce, []byte(value), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// open decrypts a value made by seal
+func open(value string) (string, error) {
+	aead, err := cipherBlock()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil || len(b) < aead.NonceSize() {
+		return "", errors.New("stored credential is corrupt")
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("stored credential cannot be decrypted; was -secretKey changed?")
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,86 @@
+package checkers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// secretKey sets SecretKey for the length of a test
+func secretKey(t *testing.T, key string) {
+	t.Helper()
+	old := SecretKey
+	SecretKey = key
+	t.Cleanup(func() { SecretKey = old })
+}
+
+func TestSealSettings(t *testing.T) {
+	secretKey(t, "test key")
+
+	sealed, err := SealSettings(`{"port": 5432, "user": "monitor", "password": "hunter2"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s dbSettings
+	if err := json.Unmarshal([]byte(sealed), &s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s.Password, sealedPrefix) || strings.Contains(sealed, "hunter2") {
+		t.Fatalf("password not sealed: %s", sealed)
+	}
+	if s.Port != 5432 || s.User != "monitor" {
+		t.Errorf("other settings changed: %s", sealed)
+	}
+
+	if got, err := secret(s.Password); err != nil || got != "hunter2" {
+		t.Errorf("secret = %q, %v; want hunter2", got, err)
+	}
+
+	//Sealing again leaves the settings alone
+	if again, err := SealSettings(sealed); err != nil || again != sealed {
+		t.Errorf("sealed settings changed when saved again: %s, %v", again, err)
+	}
+
+	//The same value seals differently each time
+	if other, _ := seal("hunter2"); other == s.Password {
+		t.Error("sealing is not randomized")
+	}
+
+	SecretKey = "another key"
+	if _, err := secret(s.Password); err == nil || !strings.Contains(err.Error(), "cannot be decrypted") {
+		t.Errorf("got %v with the wrong key, want a decryption error", err)
+	}
+}
+
+func TestSealSettingsEdgeCases(t *testing.T) {
+	secretKey(t, "")
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+		err  bool
+	}{
+		{"empty", "", "", false},
+		{"no credentials", `{"port": 6379}`, `{"port": 6379}`, false},
+		{"empty password", `{"password": ""}`, `{"password": ""}`, false},
+		{"already sealed", `{"password": "enc:v1:abc"}`, `{"password": "enc:v1:abc"}`, false},
+		{"password without key", `{"password": "hunter2"}`, "", true},
+		{"password not a string", `{"password": 5}`, "", true},
+		{"not json", `port=5`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := SealSettings(tt.raw)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%s: got %q, %v", tt.name, got, err)
+		}
+	}
+
+	if got, err := secret("plain"); err != nil || got != "plain" {
+		t.Errorf("secret(plain) = %q, %v", got, err)
+	}
+	if _, err := secret("enc:v1:abc"); err != errNoSecretKey {
+		t.Errorf("got %v reading a sealed value without a key, want %v", err, errNoSecretKey)
+	}
+}
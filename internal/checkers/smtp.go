@@ -0,0 +1,148 @@
+package checkers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ServiceSMTP is the name of the SMTP service in the services table
+const ServiceSMTP = "SMTP"
+
+func init() {
+	Register(ServiceSMTP, smtpChecker{})
+}
+
+// smtpSettings are the per-host-service settings for SMTP checks
+type smtpSettings struct {
+	mailSettings
+	// Helo is the name sent with EHLO
+	Helo string `json:"helo"`
+}
+
+// smtpChecker talks to an SMTP server: greeting, EHLO, STARTTLS and AUTH
+type smtpChecker struct{}
+
+// DefaultSettings returns the SMTP check defaults
+func (smtpChecker) DefaultSettings() interface{} {
+	return &smtpSettings{
+		mailSettings: mailSettings{Port: 25, Timeout: 20, Security: SecurityNone, Capabilities: true},
+		Helo:         "localhost",
+	}
+}
+
+// Check holds an SMTP conversation up to, but not including, sending mail
+func (c smtpChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*smtpSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	return checkMail(ctx, t, s.mailSettings, smtpProtocol{helo: s.Helo})
+}
+
+// smtpProtocol is the SMTP conversation
+type smtpProtocol struct {
+	helo string
+}
+
+// response reads a possibly multi-line reply and checks its code
+// It returns the text of each line
+func (smtpProtocol) response(m *mailConn, code string) ([]string, error) {
+	var lines []string
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 3 {
+			return nil, fmt.Errorf("malformed reply %q", truncate(line, 100))
+		}
+		if line[:3] != code {
+			return nil, errors.New(truncate(line, 200))
+		}
+		lines = append(lines, strings.TrimSpace(line[min(4, len(line)):]))
+		if len(line) == 3 || line[3] != '-' {
+			return lines, nil
+		}
+	}
+}
+
+// greeting reads the 220 greeting
+func (p smtpProtocol) greeting(m *mailConn) (string, error) {
+	lines, err := p.response(m, "220")
+	if err != nil {
+		return "", err
+	}
+	return "220 " + strings.Join(lines, " "), nil
+}
+
+// capabilities sends EHLO and returns the extensions, without the greeting line
+func (p smtpProtocol) capabilities(m *mailConn) ([]string, error) {
+	if err := m.writeLine("EHLO " + p.helo); err != nil {
+		return nil, err
+	}
+	lines, err := p.response(m, "250")
+	if err != nil {
+		return nil, err
+	}
+	return lines[1:], nil
+}
+
+// startTLS sends STARTTLS
+func (p smtpProtocol) startTLS(m *mailConn) error {
+	if err := m.writeLine("STARTTLS"); err != nil {
+		return err
+	}
+	_, err := p.response(m, "220")
+	return err
+}
+
+// login authenticates with AUTH PLAIN, or AUTH LOGIN when that is all the server offers
+func (p smtpProtocol) login(m *mailConn, caps []string, user, password string) error {
+	mechs := capabilityArgs(caps, "AUTH")
+	has := func(mech string) bool {
+		return slices.ContainsFunc(mechs, func(offered string) bool { return strings.EqualFold(offered, mech) })
+	}
+
+	switch {
+	case has("PLAIN"):
+		token := base64.StdEncoding.EncodeToString([]byte("\x00" + user + "\x00" + password))
+		if err := m.writeLine("AUTH PLAIN " + token); err != nil {
+			return err
+		}
+		_, err := p.response(m, "235")
+		return err
+	case has("LOGIN"):
+		if err := m.writeLine("AUTH LOGIN"); err != nil {
+			return err
+		}
+		for _, v := range []string{user, password} {
+			if _, err := p.response(m, "334"); err != nil {
+				return err
+			}
+			if err := m.writeLine(base64.StdEncoding.EncodeToString([]byte(v))); err != nil {
+				return err
+			}
+		}
+		_, err := p.response(m, "235")
+		return err
+	case len(mechs) == 0:
+		return errors.New("server does not offer AUTH")
+	}
+	return fmt.Errorf("server offers neither PLAIN nor LOGIN (%s)", strings.Join(mechs, " "))
+}
+
+// quit sends QUIT and reads the reply
+func (p smtpProtocol) quit(m *mailConn) {
+	if m.writeLine("QUIT") == nil {
+		p.response(m, "221")
+	}
+}
+
+// capabilityCommand names the capability phase
+func (smtpProtocol) capabilityCommand() string {
+	return "ehlo"
+}
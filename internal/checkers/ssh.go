@@ -0,0 +1,201 @@
+package checkers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ServiceSSH is the name of the SSH service in the services table
+const ServiceSSH = "SSH"
+
+// maxSSHPreamble is how many lines a server may send before its version banner
+const maxSSHPreamble = 20
+
+// errHostKeySeen ends the SSH handshake once the host key is known; the check never logs in
+var errHostKeySeen = errors.New("host key seen")
+
+func init() {
+	Register(ServiceSSH, sshChecker{})
+}
+
+// sshSettings are the per-host-service settings for SSH checks
+type sshSettings struct {
+	// Port to connect to
+	Port int `json:"port"`
+	// Timeout is the connect and handshake timeout in seconds
+	Timeout int `json:"timeout"`
+	// Expect is a regular expression the version banner must match
+	Expect string `json:"expect"`
+	// KeyExchange completes the key exchange to read the host key, without logging in
+	KeyExchange bool `json:"key_exchange"`
+	// Fingerprint is the pinned host key fingerprint, as printed by ssh-keygen -l
+	// (SHA256:... or a legacy MD5 aa:bb:...); setting it implies key_exchange
+	Fingerprint string `json:"fingerprint"`
+	// HostKeyAlgorithm asks for a key of this type, e.g. ssh-ed25519, so that the
+	// pinned fingerprint is compared with the right key
+	HostKeyAlgorithm string `json:"host_key_algorithm"`
+}
+
+// sshChecker reads an SSH server's version banner and, optionally, its host key
+type sshChecker struct{}
+
+// DefaultSettings returns the SSH check defaults
+func (sshChecker) DefaultSettings() interface{} {
+	return &sshSettings{Port: 22, Timeout: 10}
+}
+
+// validate reports settings that can never work
+func (s sshSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	return nil
+}
+
+// Check reads the banner and, when asked, compares the host key with the pinned fingerprint
+// A changed key is a problem: the server was rebuilt, or someone is in the way
+func (c sshChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*sshSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, t.network("tcp"), addr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - connection failed: %s", addr, err), Latency: time.Since(start), Err: err}
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	r := bufio.NewReader(conn)
+	banner, err := readSSHBanner(r)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - no SSH banner: %s", addr, err), Latency: time.Since(start), Err: err}
+	}
+
+	result := Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - %s", addr, truncate(banner, 100))}
+	if s.Expect != "" && !regexp.MustCompile(s.Expect).MatchString(banner) {
+		result.Status = StatusProblem
+		result.Message += fmt.Sprintf(", expected a match for %s", s.Expect)
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	if !s.KeyExchange && s.Fingerprint == "" {
+		result.Latency = time.Since(start)
+		return result
+	}
+
+	//The client sends its own version and expects to read the server's, so replay it
+	replay := &bannerConn{Conn: conn, r: io.MultiReader(strings.NewReader(banner+"\r\n"), r)}
+
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User: "vigilate",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeySeen
+		},
+		Timeout: timeout,
+	}
+	if s.HostKeyAlgorithm != "" {
+		config.HostKeyAlgorithms = []string{s.HostKeyAlgorithm}
+	}
+
+	_, _, _, err = ssh.NewClientConn(replay, addr, config)
+	result.Latency = time.Since(start)
+	if hostKey == nil {
+		if err == nil {
+			err = errors.New("no host key received")
+		}
+		result.Status = StatusProblem
+		result.Message += fmt.Sprintf(", key exchange failed: %s", err)
+		result.Err = err
+		return result
+	}
+
+	fingerprint := ssh.FingerprintSHA256(hostKey)
+	if s.Fingerprint == "" {
+		result.Message += fmt.Sprintf(", %s %s", hostKey.Type(), fingerprint)
+		return result
+	}
+
+	if !fingerprintMatches(hostKey, s.Fingerprint) {
+		result.Status = StatusProblem
+		result.Message += fmt.Sprintf(", host key changed: pinned %s, now %s %s", s.Fingerprint, hostKey.Type(), fingerprint)
+		return result
+	}
+	result.Message += fmt.Sprintf(", %s host key matches %s", hostKey.Type(), fingerprint)
+	return result
+}
+
+// readSSHBanner returns the SSH-... version line, skipping any lines the
+// server sends before it
+func readSSHBanner(r *bufio.Reader) (string, error) {
+	for i := 0; i < maxSSHPreamble; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			return line, nil
+		}
+	}
+	return "", errors.New("too many lines before the version")
+}
+
+// fingerprintMatches compares a key with a fingerprint in either ssh-keygen format
+func fingerprintMatches(key ssh.PublicKey, pinned string) bool {
+	pinned = strings.TrimSpace(pinned)
+	if strings.HasPrefix(pinned, "SHA256:") {
+		//ssh-keygen leaves the base64 padding off, some tools do not
+		return strings.TrimRight(pinned, "=") == ssh.FingerprintSHA256(key)
+	}
+	pinned = strings.TrimPrefix(pinned, "MD5:")
+	return strings.EqualFold(pinned, ssh.FingerprintLegacyMD5(key))
+}
+
+// bannerConn reads the server banner again before the rest of the connection
+type bannerConn struct {
+	net.Conn
+	r io.Reader
+}
+
+// Read reads the replayed banner, then what follows it on the connection
+func (c *bannerConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
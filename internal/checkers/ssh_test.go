@@ -0,0 +1,119 @@
+package checkers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// hostKey generates an ed25519 SSH host key
+func hostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestFingerprintMatches(t *testing.T) {
+	key := hostKey(t).PublicKey()
+	other := hostKey(t).PublicKey()
+	sha := ssh.FingerprintSHA256(key)
+	md5 := ssh.FingerprintLegacyMD5(key)
+
+	tests := []struct {
+		name   string
+		key    ssh.PublicKey
+		pinned string
+		want   bool
+	}{
+		{"sha256", key, sha, true},
+		{"sha256 padded", key, sha + "=", true},
+		{"sha256 spaces", key, " " + sha + "\n", true},
+		{"md5", key, md5, true},
+		{"md5 prefixed", key, "MD5:" + md5, true},
+		{"md5 upper case", key, strings.ToUpper(md5), true},
+		{"other key sha256", other, sha, false},
+		{"other key md5", other, md5, false},
+		{"sha256 wrong case", key, "sha256:" + strings.TrimPrefix(sha, "SHA256:"), false},
+		{"empty", key, "", false},
+	}
+	for _, tt := range tests {
+		if got := fingerprintMatches(tt.key, tt.pinned); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sshServer serves SSH on 127.0.0.1 with the host key, sending a line of
+// preamble before the version, and returns the port; nobody can log in
+func sshServer(t *testing.T, key ssh.Signer) int {
+	config := &ssh.ServerConfig{
+		ServerVersion: "SSH-2.0-VigilateTest_1.0",
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, errors.New("no logins")
+		},
+	}
+	config.AddHostKey(key)
+
+	return serve(t, func(conn net.Conn) {
+		fmt.Fprint(conn, "Authorized use only\r\n")
+		ssh.NewServerConn(conn, config)
+	})
+}
+
+func TestSSHChecker(t *testing.T) {
+	key := hostKey(t)
+	port := sshServer(t, key)
+	fingerprint := ssh.FingerprintSHA256(key.PublicKey())
+	other := ssh.FingerprintSHA256(hostKey(t).PublicKey())
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"banner", fmt.Sprintf(`{"port": %d}`, port), StatusHealthy, "SSH-2.0-VigilateTest_1.0"},
+		{"expect", fmt.Sprintf(`{"port": %d, "expect": "OpenSSH"}`, port), StatusProblem, "expected a match for OpenSSH"},
+		{"key exchange", fmt.Sprintf(`{"port": %d, "key_exchange": true}`, port), StatusHealthy, "ssh-ed25519 " + fingerprint},
+		{"pinned", fmt.Sprintf(`{"port": %d, "fingerprint": %q}`, port, fingerprint), StatusHealthy, "host key matches"},
+		{"changed", fmt.Sprintf(`{"port": %d, "fingerprint": %q}`, port, other), StatusProblem, "host key changed: pinned " + other},
+		{"no such algorithm", fmt.Sprintf(`{"port": %d, "key_exchange": true, "host_key_algorithm": "ssh-rsa"}`, port), StatusProblem, "key exchange failed"},
+		{"connection refused", fmt.Sprintf(`{"port": %d}`, closedPort(t)), StatusProblem, "connection failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceSSH, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestSSHNoBanner(t *testing.T) {
+	port := serve(t, func(conn net.Conn) {
+		fmt.Fprint(conn, strings.Repeat("hello\r\n", maxSSHPreamble+1))
+	})
+
+	r := Run(target(ServiceSSH, models.Host{IP: "127.0.0.1"}, fmt.Sprintf(`{"port": %d}`, port)))
+	wantStatus(t, r, StatusProblem)
+	if !strings.Contains(r.Message, "too many lines before the version") {
+		t.Errorf("got %q", r.Message)
+	}
+}
@@ -0,0 +1,177 @@
+package checkers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceSSLCertificate is the name of the SSL certificate service in the services table
+const ServiceSSLCertificate = "SSL Certificate"
+
+func init() {
+	Register(ServiceSSLCertificate, sslCertChecker{})
+}
+
+// sslCertSettings are the per-host-service settings for certificate expiry checks
+type sslCertSettings struct {
+	// Port to connect to; 0 uses the port in the host URL, or 443
+	Port int `json:"port"`
+	// Timeout is the handshake timeout in seconds
+	Timeout int `json:"timeout"`
+	// WarningDays reports a warning when the certificate expires within this many days
+	WarningDays int `json:"warning_days"`
+	// ProblemDays reports a problem when the certificate expires within this many days
+	ProblemDays int `json:"problem_days"`
+	tlsSettings
+}
+
+// sslCertChecker makes a TLS handshake and inspects the leaf certificate
+type sslCertChecker struct{}
+
+// DefaultSettings returns the certificate check defaults
+func (sslCertChecker) DefaultSettings() interface{} {
+	return &sslCertSettings{Timeout: 10, WarningDays: 30, ProblemDays: 7}
+}
+
+// validate reports settings that can never work
+func (s sslCertSettings) validate() error {
+	if s.Port < 0 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535, or 0 for the port in the host URL")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if s.WarningDays < 0 || s.ProblemDays < 0 {
+		return errors.New("warning_days and problem_days cannot be negative")
+	}
+	if s.ProblemDays > s.WarningDays {
+		return errors.New("problem_days cannot be more than warning_days")
+	}
+	return nil
+}
+
+// Check reports on the expiry of the host's certificate
+func (c sslCertChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*sslCertSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	serverName := s.serverName(t)
+	host := dialHost(t, serverName)
+	if host == "" {
+		err := errors.New("host has no URL, IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	//Without a name, the certificate is verified against the IP address dialled
+	if serverName == "" {
+		serverName = host
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.port(t.Host.URL)))
+
+	//Always complete the handshake so that expired certificates can be read;
+	//verification runs separately and is reported below
+	skip := s.SkipVerify
+	s.SkipVerify = true
+	var verifyErr error
+	tlsConfig, err := s.config(serverName, &verifyErr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: time.Duration(s.Timeout) * time.Second},
+		Config:    tlsConfig,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	conn, err := dialer.DialContext(ctx, t.network("tcp"), addr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - handshake failed: %s", addr, err), Err: err}
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - no certificate presented", addr)}
+	}
+	leaf := certs[0]
+
+	days := int(time.Until(leaf.NotAfter).Hours() / 24)
+	issuer := leaf.Issuer.CommonName
+	if issuer == "" {
+		issuer = leaf.Issuer.String()
+	}
+	details := fmt.Sprintf("issued by %s, SANs: %s", issuer, strings.Join(leaf.DNSNames, ", "))
+
+	result := Result{CertExpiry: leaf.NotAfter}
+
+	switch {
+	case time.Now().After(leaf.NotAfter):
+		result.Status = StatusProblem
+		result.Message = fmt.Sprintf("certificate for %s expired on %s, %s", serverName, leaf.NotAfter.Format("2006-01-02"), details)
+		return result
+	case days <= s.ProblemDays:
+		result.Status = StatusProblem
+	case days <= s.WarningDays:
+		result.Status = StatusWarning
+	default:
+		result.Status = StatusHealthy
+	}
+	result.Message = fmt.Sprintf("certificate for %s expires in %d days (%s), %s", serverName, days, leaf.NotAfter.Format("2006-01-02"), details)
+
+	//Expiry is fine, but the certificate is not trusted for this host
+	if verifyErr != nil && !skip {
+		result.Status = StatusProblem
+		result.Err = verifyErr
+		result.Message = fmt.Sprintf("%s; %s", describeTLSError(verifyErr), result.Message)
+	}
+
+	return result
+}
+
+// port returns the configured port, the port in the host URL, or 443
+func (s sslCertSettings) port(hostURL string) int {
+	if s.Port > 0 {
+		return s.Port
+	}
+
+	raw := hostURL
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	if u, err := url.Parse(raw); err == nil && u.Port() != "" {
+		if p, err := strconv.Atoi(u.Port()); err == nil {
+			return p
+		}
+	}
+	return 443
+}
+
+// dialHost returns the address to connect to: the URL host if set, then the
+// IP address (of the target's family), then the name used for verification,
+// then the host name
+func dialHost(t Target, serverName string) string {
+	if h := hostFromURL(t.Host.URL); h != "" {
+		return h
+	}
+	if a := hostAddress(t); net.ParseIP(a) != nil {
+		return a
+	}
+	if serverName != "" {
+		return serverName
+	}
+	return hostAddress(t)
+}
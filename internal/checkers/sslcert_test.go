@@ -0,0 +1,138 @@
+package checkers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+// testCert returns a self-signed certificate for localhost and 127.0.0.1 that
+// expires at notAfter
+func testCert(t *testing.T, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "vigilate test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// tlsServer accepts TLS connections on 127.0.0.1 until the test ends and
+// returns the port
+func tlsServer(t *testing.T, cert tls.Certificate) int {
+	t.Helper()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestSSLCertChecker(t *testing.T) {
+	cert := testCert(t, time.Now().Add(10*24*time.Hour))
+	port := tlsServer(t, cert)
+	ca := caFile(t, cert.Leaf)
+
+	//settings are formatted with the port and the CA file
+	tests := []struct {
+		name     string
+		host     models.Host
+		settings string
+		want     string
+	}{
+		{"valid", models.Host{IP: "127.0.0.1"}, `{"port": %d, "ca_file": %q, "warning_days": 5, "problem_days": 2}`, StatusHealthy},
+		{"warning", models.Host{IP: "127.0.0.1"}, `{"port": %d, "ca_file": %q, "warning_days": 30, "problem_days": 2}`, StatusWarning},
+		{"problem", models.Host{IP: "127.0.0.1"}, `{"port": %d, "ca_file": %q, "warning_days": 30, "problem_days": 15}`, StatusProblem},
+		{"by name", models.Host{HostName: "localhost"}, `{"port": %d, "ca_file": %q, "warning_days": 5, "problem_days": 2}`, StatusHealthy},
+		{"by url", models.Host{URL: fmt.Sprintf("https://localhost:%d", port)}, `{"port": %d, "ca_file": %q, "warning_days": 5, "problem_days": 2}`, StatusHealthy},
+		{"wrong name", models.Host{IP: "127.0.0.1"}, `{"port": %d, "ca_file": %q, "server_name": "vigilate.invalid"}`, StatusProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceSSLCertificate, tt.host, fmt.Sprintf(tt.settings, port, ca)))
+			wantStatus(t, r, tt.want)
+			if !r.CertExpiry.Equal(cert.Leaf.NotAfter) {
+				t.Errorf("expiry %s, want %s", r.CertExpiry, cert.Leaf.NotAfter)
+			}
+		})
+	}
+}
+
+func TestSSLCertSettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings string
+		valid    bool
+	}{
+		{`{"warning_days": 30, "problem_days": 7}`, true},
+		{`{"warning_days": 7, "problem_days": 7}`, true},
+		{`{"warning_days": 0, "problem_days": 0}`, true},
+		{`{"warning_days": -1, "problem_days": 0}`, false},
+		{`{"warning_days": 30, "problem_days": -7}`, false},
+		{`{"warning_days": 7, "problem_days": 30}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(ServiceSSLCertificate, tt.settings)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, want valid %v", tt.settings, err, tt.valid)
+		}
+	}
+}
+
+func TestSSLCertExpired(t *testing.T) {
+	cert := testCert(t, time.Now().Add(-time.Hour))
+	port := tlsServer(t, cert)
+
+	r := Run(target(ServiceSSLCertificate, models.Host{IP: "127.0.0.1"}, fmt.Sprintf(`{"port": %d, "skip_verify": true}`, port)))
+	wantStatus(t, r, StatusProblem)
+}
+
+func TestSSLCertUntrusted(t *testing.T) {
+	port := tlsServer(t, testCert(t, time.Now().Add(90*24*time.Hour)))
+
+	r := Run(target(ServiceSSLCertificate, models.Host{IP: "127.0.0.1"}, fmt.Sprintf(`{"port": %d}`, port)))
+	wantStatus(t, r, StatusProblem)
+}
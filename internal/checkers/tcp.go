@@ -0,0 +1,172 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ServiceTCP is the name of the TCP port service in the services table
+const ServiceTCP = "TCP Port"
+
+// maxTCPResponse is the most that is read from a TCP service when matching its response
+const maxTCPResponse = 4096
+
+func init() {
+	Register(ServiceTCP, tcpChecker{})
+}
+
+// tcpSettings are the per-host-service settings for TCP port checks
+type tcpSettings struct {
+	// Port to connect to
+	Port int `json:"port"`
+	// Timeout is the connect and read timeout in seconds
+	Timeout int `json:"timeout"`
+	// Send is written to the connection once it is open
+	Send string `json:"send"`
+	// Expect is a regular expression the banner or response must match
+	Expect string `json:"expect"`
+}
+
+// tcpChecker opens a TCP connection to the host, optionally matching its response
+type tcpChecker struct{}
+
+// DefaultSettings returns the TCP check defaults
+func (tcpChecker) DefaultSettings() interface{} {
+	return &tcpSettings{Timeout: 10}
+}
+
+// validate reports settings that can never work
+func (s tcpSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	return nil
+}
+
+// Check connects to the configured port and reports the connect time
+func (c tcpChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*tcpSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	var expect *regexp.Regexp
+	if s.Expect != "" {
+		expect = regexp.MustCompile(s.Expect)
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, t.network("tcp"), addr)
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - connection failed: %s", addr, err), Latency: latency, Err: err}
+	}
+	defer conn.Close()
+
+	if s.Send == "" && expect == nil {
+		return Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - connected in %s", addr, roundLatency(latency)), Latency: latency}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if s.Send != "" {
+		if _, err := conn.Write([]byte(s.Send)); err != nil {
+			return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - send failed: %s", addr, err), Latency: latency, Err: err}
+		}
+	}
+
+	if expect == nil {
+		return Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - connected in %s, data sent", addr, roundLatency(latency)), Latency: latency}
+	}
+
+	response, err := readUntilMatch(conn, expect)
+	if expect.Match(response) {
+		return Result{Status: StatusHealthy, Message: fmt.Sprintf("%s - connected in %s, response matched %q", addr, roundLatency(latency), s.Expect), Latency: latency}
+	}
+
+	msg := fmt.Sprintf("%s - response did not match %q", addr, s.Expect)
+	if len(response) > 0 {
+		msg = fmt.Sprintf("%s, got %q", msg, truncate(string(response), 80))
+	}
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		msg = fmt.Sprintf("%s (%s)", msg, err)
+	}
+	return Result{Status: StatusProblem, Message: msg, Latency: latency, Err: err}
+}
+
+// readUntilMatch reads from conn until the data read matches re, the
+// connection closes or times out, or maxTCPResponse bytes have been read
+func readUntilMatch(conn net.Conn, re *regexp.Regexp) ([]byte, error) {
+	var response []byte
+	buf := make([]byte, 512)
+	for len(response) < maxTCPResponse {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if re.Match(response) {
+			return response, nil
+		}
+		if err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// hostAddress returns the address used to reach a host directly: its IPv4
+// address, then its IPv6 address, then its host name
+// A check restricted to one family uses that family's address, or the host
+// name, which is then only resolved within the family
+func hostAddress(t Target) string {
+	switch {
+	case t.Family == FamilyIPv4 && t.Host.IP != "":
+		return t.Host.IP
+	case t.Family == FamilyIPv6 && t.Host.IPV6 != "":
+		return t.Host.IPV6
+	case t.Family == FamilyIPv4 || t.Family == FamilyIPv6:
+		return t.Host.HostName
+	case t.Host.IP != "":
+		return t.Host.IP
+	case t.Host.IPV6 != "":
+		return t.Host.IPV6
+	default:
+		return t.Host.HostName
+	}
+}
+
+// truncate shortens s to at most n characters for use in a message
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,116 @@
+package checkers
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+)
+
+// serve accepts connections on 127.0.0.1 until the test ends, handling each
+// with handle, and returns the port
+func serve(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port on 127.0.0.1 that nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestTCPChecker(t *testing.T) {
+	port := serve(t, func(conn net.Conn) {
+		fmt.Fprint(conn, "+OK ready\r\n")
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err == nil && strings.TrimSpace(line) == "PING" {
+			fmt.Fprint(conn, "+PONG\r\n")
+		}
+	})
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+	}{
+		{"connect", `{"port": %d}`, StatusHealthy},
+		{"banner", `{"port": %d, "expect": "^\\+OK"}`, StatusHealthy},
+		{"send and expect", `{"port": %d, "send": "PING\r\n", "expect": "PONG"}`, StatusHealthy},
+		{"no match", `{"port": %d, "expect": "SSH-2.0", "timeout": 1}`, StatusProblem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceTCP, host, fmt.Sprintf(tt.settings, port)))
+			wantStatus(t, r, tt.want)
+		})
+	}
+
+	t.Run("closed", func(t *testing.T) {
+		r := Run(target(ServiceTCP, host, fmt.Sprintf(`{"port": %d}`, closedPort(t))))
+		wantStatus(t, r, StatusProblem)
+	})
+}
+
+func TestTCPSettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings string
+		valid    bool
+	}{
+		{`{"port": 22}`, true},
+		{`{"port": 0}`, false},
+		{`{"port": 70000}`, false},
+		{`{"port": 22, "timeout": 0}`, false},
+		{`{"port": 22, "expect": "("}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(ServiceTCP, tt.settings)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, want valid %v", tt.settings, err, tt.valid)
+		}
+	}
+}
+
+func TestHostAddress(t *testing.T) {
+	tests := []struct {
+		host models.Host
+		want string
+	}{
+		{models.Host{HostName: "web1", IP: "192.0.2.1", IPV6: "2001:db8::1"}, "192.0.2.1"},
+		{models.Host{HostName: "web1", IPV6: "2001:db8::1"}, "2001:db8::1"},
+		{models.Host{HostName: "web1"}, "web1"},
+		{models.Host{}, ""},
+	}
+	for _, tt := range tests {
+		if got := hostAddress(Target{Host: tt.host}); got != tt.want {
+			t.Errorf("hostAddress(%+v) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package checkers
+
+import (
+	"fmt"
+	"time"
+	"vigilate/internal/models"
+)
+
+// applyThresholds downgrades a passing result whose response time is over the
+// host service's thresholds: over critical is a problem, over warning a warning
+// Failed checks are left alone, their latency says nothing about the service
+func applyThresholds(hs models.HostService, r Result) Result {
+	if r.Status != StatusHealthy && r.Status != StatusWarning {
+		return r
+	}
+
+	critical := time.Duration(hs.CriticalThreshold) * time.Millisecond
+	warning := time.Duration(hs.WarningThreshold) * time.Millisecond
+
+	switch {
+	case critical > 0 && r.Latency > critical:
+		r.Status = StatusProblem
+		r.Message = fmt.Sprintf("%s - response time %s over critical threshold of %s",
+			r.Message, roundLatency(r.Latency), critical)
+	case warning > 0 && r.Latency > warning && r.Status == StatusHealthy:
+		r.Status = StatusWarning
+		r.Message = fmt.Sprintf("%s - response time %s over warning threshold of %s",
+			r.Message, roundLatency(r.Latency), warning)
+	}
+
+	return r
+}
@@ -0,0 +1,44 @@
+package checkers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+)
+
+func TestApplyThresholds(t *testing.T) {
+	hs := models.HostService{WarningThreshold: 100, CriticalThreshold: 500}
+
+	tests := []struct {
+		name    string
+		hs      models.HostService
+		status  string
+		latency time.Duration
+		want    string
+		message string
+	}{
+		{"fast", hs, StatusHealthy, 50 * time.Millisecond, StatusHealthy, ""},
+		{"at warning", hs, StatusHealthy, 100 * time.Millisecond, StatusHealthy, ""},
+		{"slow", hs, StatusHealthy, 200 * time.Millisecond, StatusWarning, "over warning threshold of 100ms"},
+		{"too slow", hs, StatusHealthy, time.Second, StatusProblem, "over critical threshold of 500ms"},
+		{"warning and too slow", hs, StatusWarning, time.Second, StatusProblem, "over critical threshold"},
+		{"failed", hs, StatusProblem, time.Second, StatusProblem, ""},
+		{"unknown", hs, StatusUnknown, time.Second, StatusUnknown, ""},
+		{"no thresholds", models.HostService{}, StatusHealthy, time.Minute, StatusHealthy, ""},
+		{"critical only", models.HostService{CriticalThreshold: 500}, StatusHealthy, 200 * time.Millisecond, StatusHealthy, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := applyThresholds(tt.hs, Result{Status: tt.status, Message: "checked", Latency: tt.latency})
+			wantStatus(t, r, tt.want)
+			if tt.message == "" && r.Message != "checked" {
+				t.Errorf("message changed to %q", r.Message)
+			}
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not mention %q", r.Message, tt.message)
+			}
+		})
+	}
+}
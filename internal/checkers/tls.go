@@ -0,0 +1,181 @@
+package checkers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// tlsSettings are the TLS options shared by checkers that speak TLS
+type tlsSettings struct {
+	// SkipVerify accepts certificates that fail verification, reporting a warning instead
+	SkipVerify bool `json:"skip_verify"`
+	// CAFile is the path to a PEM bundle of CAs trusted in addition to the system roots
+	CAFile string `json:"ca_file"`
+	// ServerName overrides the name the certificate is verified against
+	ServerName string `json:"server_name"`
+}
+
+// serverName returns the name a host's certificate should be verified against
+// An explicit setting wins, then the URL host name; when the URL points at an
+// IP address (or is empty) the canonical name is used instead
+func (s tlsSettings) serverName(t Target) string {
+	if override := s.serverNameOverride(t); override != "" {
+		return override
+	}
+	return hostFromURL(t.Host.URL)
+}
+
+// serverNameOverride returns a name only when it differs from what a client
+// would derive from the URL, so redirects to other hosts verify normally
+func (s tlsSettings) serverNameOverride(t Target) string {
+	if s.ServerName != "" {
+		return s.ServerName
+	}
+
+	name := hostFromURL(t.Host.URL)
+	if name == "" || net.ParseIP(name) != nil {
+		return t.Host.CanonicalName
+	}
+	return ""
+}
+
+// hostServerName returns the name a service dialled by address, rather than
+// by URL, should present a certificate for: an explicit setting, then the
+// canonical name, then the host name unless it is an IP address, and last the
+// address dialled, which is verified against the certificate's IP SANs
+func (s tlsSettings) hostServerName(t Target) string {
+	switch {
+	case s.ServerName != "":
+		return s.ServerName
+	case t.Host.CanonicalName != "":
+		return t.Host.CanonicalName
+	case t.Host.HostName != "" && net.ParseIP(t.Host.HostName) == nil:
+		return t.Host.HostName
+	}
+	return hostAddress(t)
+}
+
+// config builds a tls.Config verifying against serverName (or the name the
+// client dials when empty)
+// When SkipVerify is set verification still runs, but its error is written
+// to verifyErr instead of failing the handshake
+func (s tlsSettings) config(serverName string, verifyErr *error) (*tls.Config, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+
+	if s.CAFile != "" {
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA bundle: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", s.CAFile)
+		}
+	}
+
+	cfg := &tls.Config{
+		ServerName: serverName,
+		RootCAs:    roots,
+	}
+
+	if s.SkipVerify {
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			name := serverName
+			if name == "" {
+				name = cs.ServerName
+			}
+			//keep the first failure when redirects open more connections
+			if err := verifyChain(cs, name, roots); err != nil && *verifyErr == nil {
+				*verifyErr = err
+			}
+			return nil
+		}
+	}
+
+	return cfg, nil
+}
+
+// verifyChain verifies the peer chain of a connection the same way the TLS
+// client would have if verification had not been skipped
+func verifyChain(cs tls.ConnectionState, serverName string, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no certificate presented")
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// describeTLSError turns certificate verification errors into short messages
+func describeTLSError(err error) string {
+	var hostErr x509.HostnameError
+	var authErr x509.UnknownAuthorityError
+	var certErr x509.CertificateInvalidError
+	var verifyErr *tls.CertificateVerificationError
+
+	if errors.As(err, &verifyErr) {
+		err = verifyErr.Err
+	}
+
+	switch {
+	case errors.As(err, &hostErr):
+		return fmt.Sprintf("certificate is not valid for %s", hostErr.Host)
+	case errors.As(err, &authErr):
+		return "certificate signed by unknown authority"
+	case errors.As(err, &certErr):
+		if certErr.Reason == x509.Expired {
+			return "certificate has expired or is not yet valid"
+		}
+		return fmt.Sprintf("invalid certificate: %s", certErr.Error())
+	default:
+		return err.Error()
+	}
+}
+
+// isTLSError reports whether err came from certificate verification
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var hostErr x509.HostnameError
+	var authErr x509.UnknownAuthorityError
+	var certErr x509.CertificateInvalidError
+
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &hostErr) ||
+		errors.As(err, &authErr) ||
+		errors.As(err, &certErr)
+}
+
+// hostFromURL returns the host name part of a URL, accepting URLs without a scheme
+func hostFromURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
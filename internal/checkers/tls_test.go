@@ -0,0 +1,29 @@
+package checkers
+
+import (
+	"testing"
+	"vigilate/internal/models"
+)
+
+func TestHostServerName(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings tlsSettings
+		host     models.Host
+		family   string
+		want     string
+	}{
+		{"setting", tlsSettings{ServerName: "mail.example.com"}, models.Host{CanonicalName: "mx1.example.com"}, "", "mail.example.com"},
+		{"canonical name", tlsSettings{}, models.Host{CanonicalName: "mx1.example.com", HostName: "mx1"}, "", "mx1.example.com"},
+		{"host name", tlsSettings{}, models.Host{HostName: "mx1", IP: "192.0.2.1"}, "", "mx1"},
+		{"host name is an ip", tlsSettings{}, models.Host{HostName: "192.0.2.9", IP: "192.0.2.1"}, "", "192.0.2.1"},
+		{"ip only", tlsSettings{}, models.Host{IP: "192.0.2.1"}, "", "192.0.2.1"},
+		{"ipv6 only", tlsSettings{}, models.Host{IP: "192.0.2.1", IPV6: "2001:db8::1"}, FamilyIPv6, "2001:db8::1"},
+		{"nothing", tlsSettings{}, models.Host{}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.settings.hostServerName(Target{Host: tt.host, Family: tt.family}); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ServiceTransaction is the name of the multi-step HTTP transaction service in the services table
+const ServiceTransaction = "HTTP Transaction"
+
+// Extraction types for transaction steps
+const (
+	ExtractRegex    = "regex"
+	ExtractJSONPath = "json_path"
+	ExtractHeader   = "header"
+)
+
+// variablePattern matches {{name}} references to transaction variables
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+func init() {
+	Register(ServiceTransaction, transactionChecker{})
+}
+
+// transactionSettings are the per-host-service settings for transaction checks
+type transactionSettings struct {
+	// Variables are available to every step as {{name}}, along with host_url,
+	// host_name and host_address
+	Variables map[string]string `json:"variables"`
+	// Steps run in order and share one cookie jar; the first failure ends the transaction
+	Steps []transactionStep `json:"steps"`
+	tlsSettings
+}
+
+// transactionStep is one request of a transaction
+type transactionStep struct {
+	// Name identifies the step in messages and metrics
+	Name string `json:"name"`
+	// URL is requested as given; when empty, Path is appended to the host URL
+	URL string `json:"url"`
+	// Timeout is the step timeout in seconds
+	Timeout int `json:"timeout"`
+	requestSettings
+	// Extract sets variables from the response for later steps
+	Extract []extraction `json:"extract"`
+}
+
+// extraction copies a value from a response into a variable
+type extraction struct {
+	// Variable is the name later steps use as {{variable}}
+	Variable string `json:"variable"`
+	// Type is regex (the first group, or the whole match), json_path or header
+	Type string `json:"type"`
+	// Value is the pattern, JSONPath or header name
+	Value string `json:"value"`
+}
+
+// transactionChecker runs an ordered list of HTTP requests, such as a login flow
+type transactionChecker struct{}
+
+// defaultTransactionStep returns the settings a step starts from
+func defaultTransactionStep() transactionStep {
+	return transactionStep{Timeout: 10, requestSettings: defaultRequestSettings(), Extract: []extraction{}}
+}
+
+// UnmarshalJSON decodes a step over the step defaults, so each step only sets
+// what it changes; unknown keys are rejected
+func (step *transactionStep) UnmarshalJSON(b []byte) error {
+	type plain transactionStep
+	p := plain(defaultTransactionStep())
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return err
+	}
+
+	*step = transactionStep(p)
+	return nil
+}
+
+// DefaultSettings returns the transaction check defaults: a single step requesting the host URL
+func (transactionChecker) DefaultSettings() interface{} {
+	step := defaultTransactionStep()
+	step.Name = "home"
+	return &transactionSettings{Variables: map[string]string{}, Steps: []transactionStep{step}}
+}
+
+// Check runs the steps in order, reporting the first that fails and the time each took
+func (c transactionChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*transactionSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	var verifyErr error
+	tlsConfig, err := s.config(s.ServerName, &verifyErr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		DialContext:     t.dialContext(&net.Dialer{Timeout: 30 * time.Second}),
+		TLSClientConfig: tlsConfig,
+	}
+	defer transport.CloseIdleConnections()
+
+	jar, _ := cookiejar.New(nil)
+
+	vars := map[string]string{
+		"host_url":     strings.TrimSuffix(t.Host.URL, "/"),
+		"host_name":    t.Host.HostName,
+		"host_address": hostAddress(t),
+	}
+	for k, v := range s.Variables {
+		vars[k] = v
+	}
+
+	result := Result{Status: StatusHealthy, Metrics: make(map[string]float64)}
+	var timings []string
+
+	for i, step := range s.Steps {
+		label := fmt.Sprintf("step %d", i+1)
+		if step.Name != "" {
+			label = fmt.Sprintf("step %d %q", i+1, step.Name)
+		}
+
+		elapsed, failure, err := step.run(ctx, t, transport, jar, vars)
+		result.Latency += elapsed
+		result.Metrics[stepMetric(i, step.Name)] = float64(elapsed) / float64(time.Millisecond)
+
+		if failure != "" {
+			result.Status = StatusProblem
+			result.Message = fmt.Sprintf("%s failed: %s", label, failure)
+			result.Err = err
+			result.Metrics["failed_step"] = float64(i + 1)
+			result.Metrics["total_ms"] = float64(result.Latency) / float64(time.Millisecond)
+			return result
+		}
+
+		timings = append(timings, fmt.Sprintf("%s %s", stepName(i, step.Name), roundLatency(elapsed)))
+	}
+
+	result.Metrics["total_ms"] = float64(result.Latency) / float64(time.Millisecond)
+	result.Message = fmt.Sprintf("%d steps in %s (%s)", len(s.Steps), roundLatency(result.Latency), strings.Join(timings, ", "))
+
+	//Completed, but only because verification was skipped
+	if verifyErr != nil {
+		result.Status = StatusWarning
+		result.Message += fmt.Sprintf(" (verification skipped: %s)", describeTLSError(verifyErr))
+		result.Err = verifyErr
+	}
+	return result
+}
+
+// duration is the longest a transaction can take: every step using its full timeout
+func (s transactionSettings) duration() time.Duration {
+	var d time.Duration
+	for _, step := range s.Steps {
+		d += step.timeout()
+	}
+	return d
+}
+
+// validate reports settings that can never work, before any request is made
+func (s transactionSettings) validate() error {
+	if len(s.Steps) == 0 {
+		return errors.New("a transaction needs at least one step")
+	}
+	for i, step := range s.Steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for _, e := range step.Extract {
+			if e.Variable == "" {
+				return fmt.Errorf("step %d: extractions need a variable", i+1)
+			}
+			switch e.Type {
+			case ExtractRegex:
+				if _, err := regexp.Compile(e.Value); err != nil {
+					return fmt.Errorf("step %d: extract %s: %w", i+1, e.Variable, err)
+				}
+			case ExtractJSONPath:
+				if _, err := parseJSONPath(e.Value); err != nil {
+					return fmt.Errorf("step %d: extract %s: %w", i+1, e.Variable, err)
+				}
+			case ExtractHeader:
+				if e.Value == "" {
+					return fmt.Errorf("step %d: extract %s: header extractions need a header name", i+1, e.Variable)
+				}
+			default:
+				return fmt.Errorf("step %d: extract %s: unknown type %q", i+1, e.Variable, e.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// run performs the step, checks the response and extracts its variables
+// It returns the time taken and, when the step fails, why
+func (step transactionStep) run(ctx context.Context, t Target, transport http.RoundTripper, jar http.CookieJar, vars map[string]string) (time.Duration, string, error) {
+	rs := step.expand(vars)
+
+	url := expandVariables(step.URL, vars)
+	if url == "" {
+		url = rs.url(strings.TrimSuffix(t.Host.URL, "/"))
+	}
+
+	timeout := step.timeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := rs.newRequest(ctx, url)
+	if err != nil {
+		return 0, fmt.Sprintf("%s - %s", url, err), err
+	}
+
+	client := &http.Client{Transport: transport, Jar: jar, CheckRedirect: rs.checkRedirect}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		elapsed := time.Since(start)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return elapsed, fmt.Sprintf("%s - timed out after %s", url, timeout), err
+		case errors.Is(err, errTooManyRedirects):
+			return elapsed, fmt.Sprintf("%s - %s", url, errors.Unwrap(err)), err
+		case isTLSError(err):
+			return elapsed, fmt.Sprintf("%s - %s", url, describeTLSError(err)), err
+		}
+		return elapsed, fmt.Sprintf("%s - error connecting", url), err
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	elapsed := time.Since(start)
+	if err != nil {
+		return elapsed, fmt.Sprintf("%s - %s, reading body: %s", url, resp.Status, err), err
+	}
+
+	if !rs.accepted(resp.StatusCode) {
+		return elapsed, fmt.Sprintf("%s - %s", url, resp.Status), nil
+	}
+	if failure := rs.assert(resp, body); failure != "" {
+		return elapsed, fmt.Sprintf("%s - %s", url, failure), nil
+	}
+
+	for _, e := range step.Extract {
+		v, err := e.extract(resp, body)
+		if err != nil {
+			return elapsed, fmt.Sprintf("%s - cannot extract %s: %s", url, e.Variable, err), err
+		}
+		vars[e.Variable] = v
+	}
+
+	return elapsed, "", nil
+}
+
+// timeout returns the step timeout, 10 seconds when none is set
+func (step transactionStep) timeout() time.Duration {
+	if step.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(step.Timeout) * time.Second
+}
+
+// expand returns the step's request settings with variables substituted
+func (step transactionStep) expand(vars map[string]string) requestSettings {
+	rs := step.requestSettings
+	rs.Path = expandVariables(rs.Path, vars)
+	rs.Body = expandVariables(rs.Body, vars)
+
+	rs.Headers = make(map[string]string, len(step.Headers))
+	for k, v := range step.Headers {
+		rs.Headers[k] = expandVariables(v, vars)
+	}
+
+	rs.Assertions = make([]assertion, len(step.Assertions))
+	for i, a := range step.Assertions {
+		a.Value = expandVariables(a.Value, vars)
+		rs.Assertions[i] = a
+	}
+	return rs
+}
+
+// extract returns the value an extraction selects from a response
+func (e extraction) extract(resp *http.Response, body []byte) (string, error) {
+	switch e.Type {
+	case ExtractRegex:
+		re, err := regexp.Compile(e.Value)
+		if err != nil {
+			return "", err
+		}
+		m := re.FindSubmatch(body)
+		if m == nil {
+			return "", fmt.Errorf("no match for %q", e.Value)
+		}
+		if len(m) > 1 {
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+	case ExtractJSONPath:
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", errors.New("body is not JSON")
+		}
+		return jsonPath(doc, e.Value)
+	case ExtractHeader:
+		v := resp.Header.Get(e.Value)
+		if v == "" {
+			return "", fmt.Errorf("no %s header", e.Value)
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown type %q", e.Type)
+}
+
+// expandVariables replaces {{name}} with the value of the variable; unknown names are left alone
+func expandVariables(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := variablePattern.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// stepName names a step for messages, falling back to its number
+func stepName(i int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("step %d", i+1)
+	}
+	return name
+}
+
+// stepMetric names the timing metric of a step, e.g. step_2_login_ms
+func stepMetric(i int, name string) string {
+	if n := metricName(name); n != "" {
+		return fmt.Sprintf("step_%d_%s_ms", i+1, n)
+	}
+	return fmt.Sprintf("step_%d_ms", i+1)
+}
@@ -0,0 +1,126 @@
+package checkers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{"token": "abc", "host_url": "http://web1", "a.b": "dotted"}
+
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain", "plain"},
+		{"Bearer {{token}}", "Bearer abc"},
+		{"{{ host_url }}/login?t={{token}}", "http://web1/login?t=abc"},
+		{"{{a.b}}", "dotted"},
+		{"{{unknown}}", "{{unknown}}"},
+		{"{{ bad name! }}", "{{ bad name! }}"},
+	}
+	for _, tt := range tests {
+		if got := expandVariables(tt.s, vars); got != tt.want {
+			t.Errorf("expandVariables(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStepMetric(t *testing.T) {
+	tests := []struct {
+		i    int
+		name string
+		want string
+	}{
+		{0, "", "step_1_ms"},
+		{1, "Log in", "step_2_log_in_ms"},
+	}
+	for _, tt := range tests {
+		if got := stepMetric(tt.i, tt.name); got != tt.want {
+			t.Errorf("stepMetric(%d, %q) = %q, want %q", tt.i, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		ok       bool
+	}{
+		{"defaults", ``, true},
+		{"no steps", `{"steps": []}`, false},
+		{"bad step", `{"steps": [{"accepted_status": ["ok"]}]}`, false},
+		{"unknown step key", `{"steps": [{"methd": "POST"}]}`, false},
+		{"extraction without variable", `{"steps": [{"extract": [{"type": "header", "value": "X-Token"}]}]}`, false},
+		{"bad extraction regex", `{"steps": [{"extract": [{"variable": "v", "type": "regex", "value": "("}]}]}`, false},
+		{"unknown extraction", `{"steps": [{"extract": [{"variable": "v", "type": "xpath", "value": "/a"}]}]}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(ServiceTransaction, tt.settings)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+func TestTransactionChecker(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("user") != "monitor" {
+			http.Error(w, "bad login", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"token": "t1"}`)
+	})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil || c.Value != "s1" || r.Header.Get("Authorization") != "Bearer t1" {
+			http.Error(w, "not logged in", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "<h1>Account of monitor</h1>")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	login := `{"name": "login", "method": "POST", "path": "/login",
+		"headers": {"Content-Type": "application/x-www-form-urlencoded"}, "body": "user={{user}}",
+		"extract": [{"variable": "token", "type": "json_path", "value": "$.token"}]}`
+	account := `{"name": "account", "url": "{{host_url}}/account", "headers": {"Authorization": "Bearer {{token}}"},
+		"assertions": [{"type": "contains", "value": "Account of {{user}}"}]}`
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"login flow", `{"variables": {"user": "monitor"}, "steps": [` + login + `, ` + account + `]}`, StatusHealthy, "2 steps in"},
+		{"login fails", `{"variables": {"user": "guest"}, "steps": [` + login + `, ` + account + `]}`, StatusProblem, `step 1 "login" failed: ` + srv.URL + `/login - 401 Unauthorized`},
+		{"skipped login", `{"variables": {"user": "monitor", "token": "t1"}, "steps": [` + account + `]}`, StatusProblem, `step 1 "account" failed`},
+		{"missing extraction", `{"variables": {"user": "monitor"}, "steps": [{"method": "POST", "path": "/login", "headers": {"Content-Type": "application/x-www-form-urlencoded"}, "body": "user={{user}}", "extract": [{"variable": "id", "type": "json_path", "value": "$.id"}]}]}`, StatusProblem, "cannot extract id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceTransaction, models.Host{URL: srv.URL}, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+
+	r := Run(target(ServiceTransaction, models.Host{URL: srv.URL}, `{"variables": {"user": "monitor"}, "steps": [`+login+`, `+account+`]}`))
+	for _, m := range []string{"step_1_login_ms", "step_2_account_ms", "total_ms"} {
+		if _, ok := r.Metrics[m]; !ok {
+			t.Errorf("no %s metric in %v", m, r.Metrics)
+		}
+	}
+}
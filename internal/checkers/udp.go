@@ -0,0 +1,134 @@
+package checkers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ServiceUDP is the name of the UDP port service in the services table
+const ServiceUDP = "UDP Port"
+
+// maxUDPResponse is the largest datagram read from a UDP service
+const maxUDPResponse = 65535
+
+func init() {
+	Register(ServiceUDP, udpChecker{})
+}
+
+// udpSettings are the per-host-service settings for UDP port checks
+type udpSettings struct {
+	// Port to send to
+	Port int `json:"port"`
+	// Timeout is how long to wait for the response, in seconds
+	Timeout int `json:"timeout"`
+	// Send is the datagram to send, as text
+	Send string `json:"send"`
+	// SendHex is the datagram to send as hex, for binary protocols; it wins over send
+	SendHex string `json:"send_hex"`
+	// Expect is a regular expression the response must match
+	Expect string `json:"expect"`
+}
+
+// udpChecker sends a datagram and waits for the service to answer
+// UDP has no connection, so a service only counts as up once it responds
+type udpChecker struct{}
+
+// DefaultSettings returns the UDP check defaults
+func (udpChecker) DefaultSettings() interface{} {
+	return &udpSettings{Timeout: 5}
+}
+
+// validate reports settings that can never work
+func (s udpSettings) validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if _, err := hex.DecodeString(strings.ReplaceAll(s.SendHex, " ", "")); err != nil {
+		return fmt.Errorf("send_hex: %w", err)
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	return nil
+}
+
+// payload returns the datagram to send
+func (s udpSettings) payload() []byte {
+	if s.SendHex != "" {
+		b, _ := hex.DecodeString(strings.ReplaceAll(s.SendHex, " ", ""))
+		return b
+	}
+	return []byte(s.Send)
+}
+
+// Check sends the datagram and reports the response time, matching the response when asked
+func (c udpChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*udpSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	host := hostAddress(t)
+	if host == "" {
+		err := errors.New("host has no IP address or name")
+		return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(s.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout)*time.Second)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, t.network("udp"), addr)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", addr, err), Err: err}
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	start := time.Now()
+	if _, err := conn.Write(s.payload()); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - send failed: %s", addr, err), Err: err}
+	}
+
+	buf := make([]byte, maxUDPResponse)
+	n, err := conn.Read(buf)
+	latency := time.Since(start)
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - port unreachable", addr), Latency: latency, Err: err}
+	case isTimeout(err):
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - no response within %ds", addr, s.Timeout), Latency: latency, Err: err}
+	case err != nil:
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", addr, err), Latency: latency, Err: err}
+	}
+
+	response := buf[:n]
+	result := Result{Status: StatusHealthy, Latency: latency}
+	result.Message = fmt.Sprintf("%s - %d byte response in %s", addr, n, roundLatency(latency))
+
+	if s.Expect != "" {
+		if !regexp.MustCompile(s.Expect).Match(response) {
+			result.Status = StatusProblem
+			result.Message = fmt.Sprintf("%s - response %q does not match %s", addr, truncate(string(response), 100), s.Expect)
+			return result
+		}
+		result.Message += fmt.Sprintf(" matching %s", s.Expect)
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package checkers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+)
+
+// udpServer answers datagrams on 127.0.0.1 with what respond returns, staying
+// silent when it returns nil, and returns the port
+func udpServer(t *testing.T, respond func(req []byte) []byte) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, maxUDPResponse)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := respond(buf[:n]); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// closedUDPPort returns a UDP port on 127.0.0.1 that nothing listens on
+func closedUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+	return port
+}
+
+func TestUDPChecker(t *testing.T) {
+	echo := udpServer(t, func(req []byte) []byte { return req })
+	silent := udpServer(t, func([]byte) []byte { return nil })
+	host := models.Host{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		settings string
+		status   string
+		message  string
+	}{
+		{"echo", fmt.Sprintf(`{"port": %d, "send": "ping"}`, echo), StatusHealthy, "4 byte response"},
+		{"expect", fmt.Sprintf(`{"port": %d, "send": "ping", "expect": "^pi"}`, echo), StatusHealthy, "matching ^pi"},
+		{"expect fails", fmt.Sprintf(`{"port": %d, "send": "ping", "expect": "^pong"}`, echo), StatusProblem, `response "ping" does not match ^pong`},
+		{"hex", fmt.Sprintf(`{"port": %d, "send": "text", "send_hex": "de ad be ef"}`, echo), StatusHealthy, "4 byte response"},
+		{"bad hex", fmt.Sprintf(`{"port": %d, "send_hex": "xyz"}`, echo), StatusProblem, "invalid settings: send_hex"},
+		{"no response", fmt.Sprintf(`{"port": %d, "timeout": 1}`, silent), StatusProblem, "no response within 1s"},
+		{"port unreachable", fmt.Sprintf(`{"port": %d}`, closedUDPPort(t)), StatusProblem, "port unreachable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceUDP, host, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+}
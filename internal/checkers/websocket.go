@@ -0,0 +1,267 @@
+package checkers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ServiceWebSocket is the name of the WebSocket service in the services table
+const ServiceWebSocket = "WebSocket"
+
+// maxWebSocketMessages is how many messages are read while waiting for a matching reply
+const maxWebSocketMessages = 100
+
+func init() {
+	Register(ServiceWebSocket, websocketChecker{})
+}
+
+// websocketSettings are the per-host-service settings for WebSocket checks
+type websocketSettings struct {
+	// Path is appended to the host URL, whose http and https schemes become ws and wss
+	Path string `json:"path"`
+	// Timeout for the whole check in seconds
+	Timeout int `json:"timeout"`
+	// Origin is sent with the upgrade; empty uses the host URL
+	Origin string `json:"origin"`
+	// Headers are added to the upgrade request
+	Headers map[string]string `json:"headers"`
+	// Subprotocols are offered with the upgrade
+	Subprotocols []string `json:"subprotocols"`
+	// Send is a text message sent once the connection is upgraded
+	Send string `json:"send"`
+	// Expect is a regular expression a message from the server must match;
+	// messages that do not match are skipped until the timeout
+	Expect string `json:"expect"`
+	tlsSettings
+}
+
+// websocketChecker opens a WebSocket to the host and, optionally, exchanges a message
+type websocketChecker struct{}
+
+// DefaultSettings returns the WebSocket check defaults
+func (websocketChecker) DefaultSettings() interface{} {
+	return &websocketSettings{Timeout: 10, Headers: map[string]string{}, Subprotocols: []string{}}
+}
+
+// validate reports settings that can never work
+func (s websocketSettings) validate() error {
+	if s.Timeout < 1 {
+		return errors.New("timeout must be at least one second")
+	}
+	if _, err := regexp.Compile(s.Expect); err != nil {
+		return fmt.Errorf("expect: %w", err)
+	}
+	return nil
+}
+
+// Check performs the upgrade and the optional exchange, timing each phase
+func (c websocketChecker) Check(ctx context.Context, t Target) Result {
+	s := c.DefaultSettings().(*websocketSettings)
+	if err := t.Settings(s); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+	if err := s.validate(); err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid settings: %s", err), Err: err}
+	}
+
+	location, origin, err := websocketURLs(t.Host.URL, s.Path, s.Origin)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("invalid URL: %s", err), Err: err}
+	}
+	wsURL := location.String()
+
+	config, err := websocket.NewConfig(wsURL, origin)
+	if err != nil {
+		return Result{Status: StatusProblem, Message: fmt.Sprintf("%s - %s", wsURL, err), Err: err}
+	}
+	config.Protocol = s.Subprotocols
+	config.Header = make(http.Header)
+	for k, v := range s.Headers {
+		config.Header.Set(k, v)
+	}
+
+	var verifyErr error
+	if location.Scheme == "wss" {
+		config.TlsConfig, err = s.config(s.serverName(t), &verifyErr)
+		if err != nil {
+			return Result{Status: StatusProblem, Message: err.Error(), Err: err}
+		}
+	}
+
+	timeout := time.Duration(s.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	mark := start
+	var phases []string
+	metrics := make(map[string]float64)
+	phase := func(name string) {
+		d := time.Since(mark)
+		mark = time.Now()
+		phases = append(phases, fmt.Sprintf("%s %s", name, roundLatency(d)))
+		metrics[name+"_ms"] = float64(d) / float64(time.Millisecond)
+	}
+	fail := func(what string, err error) Result {
+		msg := fmt.Sprintf("%s - %s: %s", wsURL, what, err)
+		switch {
+		case isTLSError(err):
+			msg = fmt.Sprintf("%s - %s: %s", wsURL, what, describeTLSError(err))
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded):
+			msg = fmt.Sprintf("%s - %s: timed out after %s", wsURL, what, timeout)
+		}
+		if len(phases) > 0 {
+			msg = fmt.Sprintf("%s (%s)", msg, strings.Join(phases, ", "))
+		}
+		return Result{Status: StatusProblem, Message: msg, Latency: time.Since(start), Err: err, Metrics: metrics}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, t.network("tcp"), websocketAddr(location))
+	if err != nil {
+		return fail("connection failed", err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	phase("connect")
+
+	if config.TlsConfig != nil {
+		tlsConn := tls.Client(conn, config.TlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fail("TLS handshake failed", err)
+		}
+		conn = tlsConn
+		phase("tls")
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		return fail("upgrade failed", err)
+	}
+	defer ws.Close()
+	phase("upgrade")
+
+	detail := "upgraded"
+	if s.Send != "" || s.Expect != "" {
+		reply, err := exchange(ws, s.Send, s.Expect)
+		if err != nil {
+			return fail("no matching reply", err)
+		}
+		phase("reply")
+		if reply != nil {
+			detail = fmt.Sprintf("reply %q", truncate(*reply, 100))
+		} else {
+			detail = "message sent"
+		}
+	}
+
+	result := Result{
+		Status:  StatusHealthy,
+		Message: fmt.Sprintf("%s - %s (%s)", wsURL, detail, strings.Join(phases, ", ")),
+		Latency: time.Since(start),
+		Metrics: metrics,
+	}
+
+	//Upgraded, but only because verification was skipped
+	if verifyErr != nil {
+		result.Status = StatusWarning
+		result.Message += fmt.Sprintf(" (verification skipped: %s)", describeTLSError(verifyErr))
+		result.Err = verifyErr
+	}
+	return result
+}
+
+// exchange sends the message, if any, and waits for a reply matching expect;
+// with no pattern it returns without reading
+func exchange(ws *websocket.Conn, send, expect string) (*string, error) {
+	if send != "" {
+		if err := websocket.Message.Send(ws, send); err != nil {
+			return nil, err
+		}
+	}
+	if expect == "" {
+		return nil, nil
+	}
+
+	re := regexp.MustCompile(expect)
+	var last string
+	for i := 0; i < maxWebSocketMessages; i++ {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			//Messages came, just not the right one; say which
+			if i > 0 && isTimeout(err) {
+				return nil, fmt.Errorf("last message %q does not match %s", truncate(last, 100), expect)
+			}
+			return nil, err
+		}
+		if re.MatchString(msg) {
+			return &msg, nil
+		}
+		last = msg
+	}
+	return nil, fmt.Errorf("none of %d messages matches %s, the last was %q", maxWebSocketMessages, expect, truncate(last, 100))
+}
+
+// websocketURLs returns the WebSocket URL for a host URL and path, and the
+// origin to send with the upgrade
+func websocketURLs(hostURL, path, origin string) (*url.URL, string, error) {
+	raw := strings.TrimSpace(hostURL)
+	if raw == "" {
+		return nil, "", errors.New("host has no URL")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	if path != "" {
+		raw = strings.TrimSuffix(raw, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("no host in %s", hostURL)
+	}
+
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return nil, "", fmt.Errorf("unsupported scheme %s", u.Scheme)
+	}
+
+	if origin == "" {
+		scheme := "http"
+		if u.Scheme == "wss" {
+			scheme = "https"
+		}
+		origin = scheme + "://" + u.Host
+	}
+	return u, origin, nil
+}
+
+// websocketAddr returns the host and port to dial for a WebSocket URL
+func websocketAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "wss" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
@@ -0,0 +1,115 @@
+package checkers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vigilate/internal/models"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWebsocketURLs(t *testing.T) {
+	tests := []struct {
+		hostURL    string
+		path       string
+		origin     string
+		wantURL    string
+		wantOrigin string
+		ok         bool
+	}{
+		{"http://example.com", "", "", "ws://example.com", "http://example.com", true},
+		{"https://example.com/", "/socket", "", "wss://example.com/socket", "https://example.com", true},
+		{"example.com:8080", "live", "", "ws://example.com:8080/live", "http://example.com:8080", true},
+		{"wss://example.com/app", "feed", "https://app.example.com", "wss://example.com/app/feed", "https://app.example.com", true},
+		{"ws://[2001:db8::1]:9000", "", "", "ws://[2001:db8::1]:9000", "http://[2001:db8::1]:9000", true},
+		{"", "", "", "", "", false},
+		{"ftp://example.com", "", "", "", "", false},
+		{"http://", "", "", "", "", false},
+	}
+	for _, tt := range tests {
+		u, origin, err := websocketURLs(tt.hostURL, tt.path, tt.origin)
+		if (err == nil) != tt.ok {
+			t.Errorf("websocketURLs(%q, %q) error %v, want ok %t", tt.hostURL, tt.path, err, tt.ok)
+			continue
+		}
+		if tt.ok && (u.String() != tt.wantURL || origin != tt.wantOrigin) {
+			t.Errorf("websocketURLs(%q, %q) = %s, %s; want %s, %s", tt.hostURL, tt.path, u, origin, tt.wantURL, tt.wantOrigin)
+		}
+	}
+}
+
+// websocketServer serves /echo, which says hello and then echoes every
+// message, and /private, which refuses upgrades without X-Token: secret
+func websocketServer() *httptest.Server {
+	echo := func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, "hello")
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			websocket.Message.Send(ws, "echo: "+msg)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/echo", websocket.Handler(echo))
+	mux.Handle("/private", websocket.Server{
+		Handler: echo,
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			if r.Header.Get("X-Token") != "secret" {
+				return errors.New("no token")
+			}
+			return nil
+		},
+	})
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	return srv
+}
+
+func TestWebSocketChecker(t *testing.T) {
+	srv := websocketServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		settings string
+		status   string
+		message  string
+	}{
+		{"upgrade", srv.URL, `{"path": "/echo"}`, StatusHealthy, "upgraded (connect"},
+		{"greeting", srv.URL, `{"path": "/echo", "expect": "^hello$"}`, StatusHealthy, `reply "hello"`},
+		{"exchange", srv.URL, `{"path": "/echo", "send": "ping", "expect": "ping"}`, StatusHealthy, `reply "echo: ping"`},
+		{"send only", srv.URL, `{"path": "/echo", "send": "ping"}`, StatusHealthy, "message sent"},
+		{"no match", srv.URL, `{"path": "/echo", "send": "ping", "expect": "pong", "timeout": 1}`, StatusProblem, `last message "echo: ping" does not match pong`},
+		{"header", srv.URL, `{"path": "/private", "headers": {"X-Token": "secret"}}`, StatusHealthy, "upgraded"},
+		{"refused", srv.URL, `{"path": "/private"}`, StatusProblem, "upgrade failed"},
+		{"not a websocket", srv.URL, `{"path": "/nothing"}`, StatusProblem, "upgrade failed"},
+		{"no url", "", ``, StatusProblem, "invalid URL: host has no URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Run(target(ServiceWebSocket, models.Host{URL: tt.url}, tt.settings))
+			wantStatus(t, r, tt.status)
+			if !strings.Contains(r.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.message)
+			}
+		})
+	}
+
+	r := Run(target(ServiceWebSocket, models.Host{URL: srv.URL}, `{"path": "/echo", "expect": "hello"}`))
+	for _, m := range []string{"connect_ms", "upgrade_ms", "reply_ms"} {
+		if _, ok := r.Metrics[m]; !ok {
+			t.Errorf("no %s metric in %v", m, r.Metrics)
+		}
+	}
+}
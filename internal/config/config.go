@@ -2,11 +2,11 @@ package config
 
 import (
 	"html/template"
+	"vigilate/internal/broadcast"
 	"vigilate/internal/channeldata"
 	"vigilate/internal/driver"
 
 	"github.com/alexedwards/scs/v2"
-	"github.com/pusher/pusher-http-go"
 	"github.com/robfig/cron/v3"
 )
 
@@ -22,7 +22,7 @@ type AppConfig struct {
 	MonitorMap    map[int]cron.EntryID
 	PreferenceMap map[string]string
 	Scheduler     *cron.Cron
-	WsClient      pusher.Client
+	WsClient      broadcast.Broadcaster
 	PusherSecret  string
 	TemplateCache map[string]*template.Template
 	MailQueue     chan channeldata.MailJob
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"vigilate/internal/checkers"
 	"vigilate/internal/helpers"
+	"vigilate/internal/models"
 
 	"github.com/CloudyKit/jet/v6"
 )
@@ -13,7 +16,7 @@ import (
 // AllHealthyServices renders healthy services page
 func (repo *DBRepo) AllHealthyServices(w http.ResponseWriter, r *http.Request) {
 	//get all host services (with host info) for status pending
-	services, err := repo.DB.GetServicesByStatus("healthy")
+	services, err := repo.servicesByStatus("healthy")
 	if err != nil {
 		log.Println(err)
 		return
@@ -31,7 +34,7 @@ func (repo *DBRepo) AllHealthyServices(w http.ResponseWriter, r *http.Request) {
 // AllWarningServices renders warning services page
 func (repo *DBRepo) AllWarningServices(w http.ResponseWriter, r *http.Request) {
 	//get all host services (with host info) for status pending
-	services, err := repo.DB.GetServicesByStatus("warning")
+	services, err := repo.servicesByStatus("warning")
 	if err != nil {
 		log.Println(err)
 		return
@@ -47,7 +50,7 @@ func (repo *DBRepo) AllWarningServices(w http.ResponseWriter, r *http.Request) {
 // AllProblemsServices renders problemn services page
 func (repo *DBRepo) AllProblemServices(w http.ResponseWriter, r *http.Request) {
 	//get all host services (with host info) for status pending
-	services, err := repo.DB.GetServicesByStatus("problem")
+	services, err := repo.servicesByStatus("problem")
 	if err != nil {
 		log.Println(err)
 		return
@@ -64,7 +67,7 @@ func (repo *DBRepo) AllProblemServices(w http.ResponseWriter, r *http.Request) {
 // AllPendingServices renders pending services page
 func (repo *DBRepo) AllPendingServices(w http.ResponseWriter, r *http.Request) {
 	//get all host services (with host info) for status pending
-	services, err := repo.DB.GetServicesByStatus("pending")
+	services, err := repo.servicesByStatus("pending")
 	if err != nil {
 		log.Println(err)
 		return
@@ -78,3 +81,38 @@ func (repo *DBRepo) AllPendingServices(w http.ResponseWriter, r *http.Request) {
 		printTemplateError(w, err)
 	}
 }
+
+// AllUnknownServices renders unknown services page
+func (repo *DBRepo) AllUnknownServices(w http.ResponseWriter, r *http.Request) {
+	//get all host services (with host info) for status unknown
+	services, err := repo.servicesByStatus("unknown")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("services", services)
+
+	err = helpers.RenderPage(w, r, "unknown", vars, nil)
+	if err != nil {
+		printTemplateError(w, err)
+	}
+}
+
+// servicesByStatus returns host services with the given status, flagging
+// services whose type has no registered checker
+func (repo *DBRepo) servicesByStatus(status string) ([]models.HostService, error) {
+	services, err := repo.DB.GetServicesByStatus(status)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range services {
+		if _, ok := checkers.Lookup(services[i].Service.ServiceName); !ok {
+			services[i].LastMessage = fmt.Sprintf("no checker registered for service %q", services[i].Service.ServiceName)
+		}
+	}
+
+	return services, nil
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"vigilate/internal/models"
+
+	"github.com/go-chi/chi"
+)
+
+// checkResultsPageSize is the number of check results returned per page
+const checkResultsPageSize = 50
+
+// checkResultJSON is a single check result sent to the client
+type checkResultJSON struct {
+	ID        int                `json:"id"`
+	Status    string             `json:"status"`
+	Message   string             `json:"message"`
+	LatencyMS int64              `json:"latency_ms"`
+	Error     string             `json:"error"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	CheckedAt time.Time          `json:"checked_at"`
+}
+
+// checkResultsJSON is one page of check results sent to the client
+type checkResultsJSON struct {
+	OK      bool              `json:"ok"`
+	Message string            `json:"message"`
+	Page    int               `json:"page"`
+	Pages   int               `json:"pages"`
+	Total   int               `json:"total"`
+	Results []checkResultJSON `json:"results"`
+}
+
+// CheckResults returns a page of check history for a host service as JSON
+// Optional query parameters: page (from 1), from and to (YYYY-MM-DD or RFC3339)
+func (repo *DBRepo) CheckResults(w http.ResponseWriter, r *http.Request) {
+	hostServiceID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	var resp checkResultsJSON
+	resp.OK = true
+	resp.Page = page
+	resp.Results = []checkResultJSON{}
+
+	from, err := parseTimeParam(r.URL.Query().Get("from"))
+	if err != nil {
+		resp.OK = false
+		resp.Message = "Invalid from date"
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"))
+	if err != nil {
+		resp.OK = false
+		resp.Message = "Invalid to date"
+	}
+	//a plain date includes the whole day
+	if isPlainDate(r.URL.Query().Get("to")) {
+		to = to.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	if resp.OK {
+		var results []models.CheckResult
+		results, resp.Total, err = repo.DB.GetCheckResults(hostServiceID, from, to, checkResultsPageSize, (page-1)*checkResultsPageSize)
+		if err != nil {
+			log.Println(err)
+			resp.OK = false
+			resp.Message = "Something went wrong!"
+		}
+
+		resp.Pages = (resp.Total + checkResultsPageSize - 1) / checkResultsPageSize
+		for _, x := range results {
+			resp.Results = append(resp.Results, checkResultJSON{
+				ID:        x.ID,
+				Status:    x.Status,
+				Message:   x.Message,
+				LatencyMS: x.Latency.Milliseconds(),
+				Error:     x.Error,
+				Metrics:   x.Metrics,
+				CheckedAt: x.CheckedAt,
+			})
+		}
+	}
+
+	out, _ := json.MarshalIndent(resp, "", "  ")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// parseTimeParam parses a date (YYYY-MM-DD) or RFC3339 timestamp; empty gives the zero time
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// isPlainDate reports whether v is a date (YYYY-MM-DD) rather than a timestamp
+func isPlainDate(v string) bool {
+	_, err := time.Parse("2006-01-02", v)
+	return err == nil
+}
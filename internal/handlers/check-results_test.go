@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+	"vigilate/internal/repository"
+
+	"github.com/go-chi/chi"
+)
+
+// checkResultsRepo is a repository that returns results and records what was asked for
+type checkResultsRepo struct {
+	repository.DatabaseRepo
+	results []models.CheckResult
+	total   int
+	err     error
+
+	hostServiceID int
+	from, to      time.Time
+	limit, offset int
+}
+
+func (r *checkResultsRepo) GetCheckResults(hostServiceID int, from, to time.Time, limit, offset int) ([]models.CheckResult, int, error) {
+	r.hostServiceID, r.from, r.to, r.limit, r.offset = hostServiceID, from, to, limit, offset
+	return r.results, r.total, r.err
+}
+
+// getCheckResults calls the CheckResults handler for host service 7 and decodes the response
+func getCheckResults(t *testing.T, db *checkResultsRepo, query string) checkResultsJSON {
+	t.Helper()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req := httptest.NewRequest(http.MethodGet, "/admin/host-service/7/results?"+query, nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	repo := &DBRepo{DB: db}
+	repo.CheckResults(rr, req)
+
+	var resp checkResultsJSON
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("cannot decode %q: %s", rr.Body.String(), err)
+	}
+	return resp
+}
+
+func TestCheckResults(t *testing.T) {
+	checked := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	db := &checkResultsRepo{
+		results: []models.CheckResult{
+			{ID: 2, Status: "problem", Message: "refused", Latency: 1500 * time.Millisecond, Error: "dial tcp: refused", CheckedAt: checked},
+			{ID: 1, Status: "healthy", Latency: 20 * time.Millisecond, Metrics: map[string]float64{"rtt_avg_ms": 1.5}, CheckedAt: checked.Add(-time.Minute)},
+		},
+		total: 120,
+	}
+
+	resp := getCheckResults(t, db, "")
+	if !resp.OK || resp.Page != 1 || resp.Pages != 3 || resp.Total != 120 || len(resp.Results) != 2 {
+		t.Fatalf("got %+v", resp)
+	}
+	if db.hostServiceID != 7 || db.limit != checkResultsPageSize || db.offset != 0 || !db.from.IsZero() || !db.to.IsZero() {
+		t.Errorf("asked for host service %d, %s to %s, limit %d offset %d", db.hostServiceID, db.from, db.to, db.limit, db.offset)
+	}
+	got := resp.Results[0]
+	if got.ID != 2 || got.Status != "problem" || got.LatencyMS != 1500 || got.Error != "dial tcp: refused" || !got.CheckedAt.Equal(checked) {
+		t.Errorf("first result %+v", got)
+	}
+	if resp.Results[1].Metrics["rtt_avg_ms"] != 1.5 {
+		t.Errorf("second result metrics %v", resp.Results[1].Metrics)
+	}
+
+	getCheckResults(t, db, "page=3")
+	if db.offset != 2*checkResultsPageSize || db.limit != checkResultsPageSize {
+		t.Errorf("page 3: limit %d offset %d", db.limit, db.offset)
+	}
+}
+
+func TestCheckResultsRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		from, to time.Time
+	}{
+		{"dates", "from=2026-03-01&to=2026-03-02",
+			time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+			time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)},
+		{"timestamps", "from=2026-03-01T08:00:00Z&to=2026-03-01T09:00:00Z",
+			time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+			time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)},
+		{"open end", "from=2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &checkResultsRepo{}
+			resp := getCheckResults(t, db, tt.query)
+			if !resp.OK {
+				t.Fatalf("got %+v", resp)
+			}
+			if !db.from.Equal(tt.from) || !db.to.Equal(tt.to) {
+				t.Errorf("asked for %s to %s, want %s to %s", db.from, db.to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestCheckResultsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		err     error
+		message string
+	}{
+		{"bad from", "from=yesterday", nil, "Invalid from date"},
+		{"bad to", "to=2026-13-01", nil, "Invalid to date"},
+		{"repository error", "", errors.New("connection refused"), "Something went wrong!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &checkResultsRepo{err: tt.err, limit: -1}
+			resp := getCheckResults(t, db, tt.query)
+			if resp.OK || resp.Message != tt.message || len(resp.Results) != 0 {
+				t.Errorf("got %+v, want %q", resp, tt.message)
+			}
+			if tt.err == nil && db.limit != -1 {
+				t.Error("the repository was queried with an invalid date")
+			}
+		})
+	}
+}
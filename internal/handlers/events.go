@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"vigilate/internal/checkers"
+	"vigilate/internal/helpers"
+	"vigilate/internal/models"
+
+	"github.com/CloudyKit/jet/v6"
+)
+
+// The event log: events are written when host services change status or are
+// toggled, and when monitoring starts or stops, and shown on the paginated,
+// filterable events page
+
+// eventsPageSize is the number of events shown per page
+const eventsPageSize = 25
+
+// Events displays the event log with filters and server-side pagination
+func (repo *DBRepo) Events(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var filter models.EventFilter
+	filter.HostID, _ = strconv.Atoi(q.Get("host_id"))
+	filter.ServiceID, _ = strconv.Atoi(q.Get("service_id"))
+	filter.EventType = q.Get("event_type")
+
+	//Ignore dates that do not parse rather than failing the page
+	from, err := parseTimeParam(q.Get("from"))
+	if err == nil {
+		filter.From = from
+	}
+	to, err := parseTimeParam(q.Get("to"))
+	if err == nil && !to.IsZero() {
+		filter.To = to
+		//a plain date includes the whole day
+		if isPlainDate(q.Get("to")) {
+			filter.To = to.Add(24*time.Hour - time.Nanosecond)
+		}
+	}
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	events, total, err := repo.DB.GetEvents(filter, eventsPageSize, (page-1)*eventsPageSize)
+	if err != nil {
+		ServerError(w, r, err)
+		return
+	}
+
+	hosts, err := repo.DB.AllHosts()
+	if err != nil {
+		ServerError(w, r, err)
+		return
+	}
+
+	services, err := repo.DB.AllServices()
+	if err != nil {
+		ServerError(w, r, err)
+		return
+	}
+
+	//Query string without the page, so pager links keep the filters
+	q.Del("page")
+
+	vars := make(jet.VarMap)
+	vars.Set("events", events)
+	vars.Set("hosts", hosts)
+	vars.Set("services", services)
+	vars.Set("eventTypes", models.EventTypes)
+	vars.Set("filter", filter)
+	vars.Set("fromDate", q.Get("from"))
+	vars.Set("toDate", q.Get("to"))
+
+	pages := (total + eventsPageSize - 1) / eventsPageSize
+	prevLink, nextLink := "", ""
+	if page > 1 {
+		prevLink = pageLink(q.Encode(), page-1)
+	}
+	if page < pages {
+		nextLink = pageLink(q.Encode(), page+1)
+	}
+
+	vars.Set("page", page)
+	vars.Set("pages", pages)
+	vars.Set("total", total)
+	vars.Set("prevLink", prevLink)
+	vars.Set("nextLink", nextLink)
+
+	err = helpers.RenderPage(w, r, "events", vars, nil)
+	if err != nil {
+		printTemplateError(w, err)
+	}
+}
+
+// logEvent writes an entry to the event log
+func (repo *DBRepo) logEvent(e models.Event) {
+	_, err := repo.DB.InsertEvent(e)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// logStatusChange records a host service moving from one status to another
+func (repo *DBRepo) logStatusChange(h models.Host, hs models.HostService, oldStatus string, result checkers.Result) {
+	repo.logEvent(models.Event{
+		EventType:     models.EventStatusChanged,
+		HostServiceID: hs.ID,
+		HostID:        h.ID,
+		ServiceID:     hs.ServiceID,
+		HostName:      h.HostName,
+		ServiceName:   hs.Service.ServiceName,
+		Message: fmt.Sprintf("%s on %s changed from %s to %s: %s",
+			hs.Service.ServiceName, h.HostName, oldStatus, result.Status, result.Message),
+	})
+}
+
+// logServiceToggled records a service being turned on or off for a host
+func (repo *DBRepo) logServiceToggled(hostID, serviceID, active int) {
+	h, err := repo.DB.GetHostByID(hostID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	e := models.Event{
+		EventType: models.EventServiceDisabled,
+		HostID:    h.ID,
+		ServiceID: serviceID,
+		HostName:  h.HostName,
+	}
+	if active == 1 {
+		e.EventType = models.EventServiceEnabled
+	}
+
+	for _, hs := range h.HostServices {
+		if hs.ServiceID == serviceID {
+			e.HostServiceID = hs.ID
+			e.ServiceName = hs.Service.ServiceName
+		}
+	}
+
+	state := "off"
+	if active == 1 {
+		state = "on"
+	}
+	e.Message = fmt.Sprintf("%s on %s was turned %s", e.ServiceName, h.HostName, state)
+
+	repo.logEvent(e)
+}
+
+// pageLink builds the URL of an events page, keeping the current filters
+func pageLink(query string, page int) string {
+	v, _ := url.ParseQuery(query)
+	v.Set("page", strconv.Itoa(page))
+	return "/admin/events?" + v.Encode()
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeParam(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		plain   bool
+		wantErr bool
+	}{
+		{"", time.Time{}, false, false},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), true, false},
+		{"2026-03-01T10:30:00Z", time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC), false, false},
+		{"yesterday", time.Time{}, false, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeParam(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeParam(%q) error %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeParam(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if isPlainDate(tt.in) != tt.plain {
+			t.Errorf("isPlainDate(%q) = %v, want %v", tt.in, !tt.plain, tt.plain)
+		}
+	}
+}
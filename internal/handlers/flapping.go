@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"vigilate/internal/checkers"
+	"vigilate/internal/models"
+)
+
+//Package handlers contains failure confirmation and flap detection: a failed
+//check is retried a number of times before the service is put in problem,
+//and a service whose status keeps changing is marked as flapping, which holds
+//back notifications until it settles
+
+// Flap detection looks at the last flapWindow check results; the service starts
+// flapping when at least flapHigh percent of them changed status, and stops
+// when fewer than flapLow percent did; fewer than flapMinResults results are not judged
+const (
+	flapWindow     = 21
+	flapMinResults = 6
+	flapHigh       = 50.0
+	flapLow        = 25.0
+)
+
+// retryTimers holds the pending retry for each host service, so failures do not stack retries
+var retryTimers = struct {
+	sync.Mutex
+	m map[int]*time.Timer
+}{m: make(map[int]*time.Timer)}
+
+// confirmFailure counts consecutive problem results and reports whether the
+// result should be taken as it is; a problem is only confirmed once it has
+// failed MaxRetries times more, with a retry scheduled after each failure
+func (repo *DBRepo) confirmFailure(h models.Host, hs *models.HostService, result checkers.Result) bool {
+	if result.Status != checkers.StatusProblem {
+		hs.FailureCount = 0
+		return true
+	}
+
+	hs.FailureCount++
+	if hs.Status == checkers.StatusProblem || hs.FailureCount > hs.MaxRetries {
+		return true
+	}
+
+	repo.logEvent(models.Event{
+		EventType:     models.EventCheckRetry,
+		HostServiceID: hs.ID,
+		HostID:        h.ID,
+		ServiceID:     hs.ServiceID,
+		HostName:      h.HostName,
+		ServiceName:   hs.Service.ServiceName,
+		Message: fmt.Sprintf("%s on %s failed (%d of %d), retrying in %ds: %s",
+			hs.Service.ServiceName, h.HostName, hs.FailureCount, hs.MaxRetries+1, hs.RetryInterval, result.Message),
+	})
+
+	scheduleRetry(hs.ID, time.Duration(hs.RetryInterval)*time.Second)
+	return false
+}
+
+// scheduleRetry runs an extra check of a host service after a delay, unless one is already waiting
+func scheduleRetry(hostServiceID int, delay time.Duration) {
+	if delay < time.Second {
+		delay = time.Second
+	}
+
+	retryTimers.Lock()
+	defer retryTimers.Unlock()
+
+	if _, ok := retryTimers.m[hostServiceID]; ok {
+		return
+	}
+
+	retryTimers.m[hostServiceID] = time.AfterFunc(delay, func() {
+		retryTimers.Lock()
+		delete(retryTimers.m, hostServiceID)
+		retryTimers.Unlock()
+
+		//Monitoring may have been turned off while waiting
+		if app.PreferenceMap["monitoring_live"] != "1" {
+			return
+		}
+		Repo.ScheduledCheck(hostServiceID)
+	})
+}
+
+// cancelRetry stops the pending retry of a host service, if there is one
+func cancelRetry(hostServiceID int) {
+	retryTimers.Lock()
+	defer retryTimers.Unlock()
+
+	if t, ok := retryTimers.m[hostServiceID]; ok {
+		t.Stop()
+		delete(retryTimers.m, hostServiceID)
+	}
+}
+
+// cancelServiceRetry stops the pending retry of a service on a host
+func (repo *DBRepo) cancelServiceRetry(hostID, serviceID int) {
+	h, err := repo.DB.GetHostByID(hostID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, hs := range h.HostServices {
+		if hs.ServiceID == serviceID {
+			cancelRetry(hs.ID)
+		}
+	}
+}
+
+// detectFlapping updates the flapping state of a host service from its recent
+// check results, logging when it starts and stops
+func (repo *DBRepo) detectFlapping(h models.Host, hs *models.HostService) {
+	if hs.FlapDetection != 1 {
+		hs.Flapping = 0
+		return
+	}
+
+	statuses, err := repo.DB.GetRecentCheckStatuses(hs.ID, flapWindow)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(statuses) < flapMinResults {
+		return
+	}
+	pct := stateChangePercent(statuses)
+
+	e := models.Event{
+		HostServiceID: hs.ID,
+		HostID:        h.ID,
+		ServiceID:     hs.ServiceID,
+		HostName:      h.HostName,
+		ServiceName:   hs.Service.ServiceName,
+	}
+
+	switch {
+	case hs.Flapping == 0 && pct >= flapHigh:
+		hs.Flapping = 1
+		e.EventType = models.EventFlappingStarted
+		e.Message = fmt.Sprintf("%s on %s is flapping (%.0f%% state change over the last %d checks); notifications are held back until it settles",
+			hs.Service.ServiceName, h.HostName, pct, len(statuses))
+	case hs.Flapping == 1 && pct < flapLow:
+		hs.Flapping = 0
+		e.EventType = models.EventFlappingStopped
+		e.Message = fmt.Sprintf("%s on %s stopped flapping (%.0f%% state change) and is %s",
+			hs.Service.ServiceName, h.HostName, pct, hs.Status)
+	default:
+		return
+	}
+
+	repo.logEvent(e)
+}
+
+// stateChangePercent returns how many of the changes possible between
+// consecutive results were actual status changes, as a percentage
+func stateChangePercent(statuses []string) float64 {
+	if len(statuses) < 2 {
+		return 0
+	}
+
+	changes := 0
+	for i := 1; i < len(statuses); i++ {
+		if statuses[i] != statuses[i-1] {
+			changes++
+		}
+	}
+	return float64(changes) / float64(len(statuses)-1) * 100
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vigilate/internal/checkers"
+	"vigilate/internal/models"
+	"vigilate/internal/repository"
+)
+
+// eventRepo is a repository that records the events logged to it and
+// returns statuses as the recent check results
+type eventRepo struct {
+	repository.DatabaseRepo
+	events   []models.Event
+	statuses []string
+}
+
+func (r *eventRepo) InsertEvent(e models.Event) (int, error) {
+	r.events = append(r.events, e)
+	return len(r.events), nil
+}
+
+func (r *eventRepo) GetRecentCheckStatuses(hostServiceID, limit int) ([]string, error) {
+	return r.statuses[:min(limit, len(r.statuses))], nil
+}
+
+func TestStateChangePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses string
+		want     float64
+	}{
+		{"none", "", 0},
+		{"one", "h", 0},
+		{"steady", "hhhhh", 0},
+		{"one change", "hhhpp", 25},
+		{"alternating", "hphph", 100},
+		{"half", "hhpphhp", 50},
+	}
+	names := map[rune]string{'h': checkers.StatusHealthy, 'p': checkers.StatusProblem}
+	for _, tt := range tests {
+		var statuses []string
+		for _, c := range tt.statuses {
+			statuses = append(statuses, names[c])
+		}
+		if got := stateChangePercent(statuses); got != tt.want {
+			t.Errorf("%s: got %.1f, want %.1f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectFlapping(t *testing.T) {
+	alternating := strings.Repeat(checkers.StatusHealthy+","+checkers.StatusProblem+",", 15)
+	steady := strings.Repeat(checkers.StatusHealthy+",", 30)
+
+	tests := []struct {
+		name      string
+		detection int
+		flapping  int
+		statuses  string
+		want      int
+		event     string
+	}{
+		{"too few results", 1, 0, alternating[:40], 0, ""},
+		{"starts", 1, 0, alternating, 1, models.EventFlappingStarted},
+		{"keeps flapping", 1, 1, alternating, 1, ""},
+		{"stops", 1, 1, steady, 0, models.EventFlappingStopped},
+		{"detection off", 0, 1, alternating, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &eventRepo{statuses: strings.Split(strings.TrimSuffix(tt.statuses, ","), ",")}
+			repo := &DBRepo{DB: db}
+			hs := &models.HostService{FlapDetection: tt.detection, Flapping: tt.flapping}
+
+			repo.detectFlapping(models.Host{HostName: "web1"}, hs)
+			if hs.Flapping != tt.want {
+				t.Errorf("flapping %d, want %d", hs.Flapping, tt.want)
+			}
+			switch {
+			case tt.event == "" && len(db.events) > 0:
+				t.Errorf("events %+v, want none", db.events)
+			case tt.event != "" && (len(db.events) != 1 || db.events[0].EventType != tt.event):
+				t.Errorf("events %+v, want one %s", db.events, tt.event)
+			}
+		})
+	}
+}
+
+func TestConfirmFailure(t *testing.T) {
+	problem := checkers.Result{Status: checkers.StatusProblem, Message: "connection refused"}
+	healthy := checkers.Result{Status: checkers.StatusHealthy}
+
+	tests := []struct {
+		name         string
+		status       string
+		failures     int
+		maxRetries   int
+		result       checkers.Result
+		want         bool
+		wantFailures int
+		wantRetry    bool
+	}{
+		{"healthy resets", checkers.StatusHealthy, 2, 3, healthy, true, 0, false},
+		{"no retries", checkers.StatusHealthy, 0, 0, problem, true, 1, false},
+		{"first failure", checkers.StatusHealthy, 0, 2, problem, false, 1, true},
+		{"last retry", checkers.StatusHealthy, 1, 2, problem, false, 2, true},
+		{"retries used up", checkers.StatusHealthy, 2, 2, problem, true, 3, false},
+		{"already problem", checkers.StatusProblem, 5, 2, problem, true, 6, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &eventRepo{}
+			repo := &DBRepo{DB: db}
+			hs := &models.HostService{ID: 1000 + i, Status: tt.status, FailureCount: tt.failures, MaxRetries: tt.maxRetries, RetryInterval: 60}
+			t.Cleanup(func() { cancelRetry(hs.ID) })
+
+			if got := repo.confirmFailure(models.Host{HostName: "web1"}, hs, tt.result); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+			if hs.FailureCount != tt.wantFailures {
+				t.Errorf("failure count %d, want %d", hs.FailureCount, tt.wantFailures)
+			}
+
+			retryTimers.Lock()
+			_, retrying := retryTimers.m[hs.ID]
+			retryTimers.Unlock()
+			if retrying != tt.wantRetry {
+				t.Errorf("retry scheduled %t, want %t", retrying, tt.wantRetry)
+			}
+			if tt.wantRetry && (len(db.events) != 1 || db.events[0].EventType != models.EventCheckRetry) {
+				t.Errorf("events %+v, want one %s", db.events, models.EventCheckRetry)
+			}
+		})
+	}
+}
+
+func TestCancelRetry(t *testing.T) {
+	scheduleRetry(999, time.Minute)
+	cancelRetry(999)
+
+	retryTimers.Lock()
+	defer retryTimers.Unlock()
+	if _, ok := retryTimers.m[999]; ok {
+		t.Error("retry still pending after cancelRetry")
+	}
+}
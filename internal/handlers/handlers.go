@@ -8,6 +8,7 @@ import (
 	"runtime/debug"
 	"strconv"
 
+	"vigilate/internal/checkers"
 	"vigilate/internal/config"
 	"vigilate/internal/driver"
 	"vigilate/internal/helpers"
@@ -50,7 +51,7 @@ func NewPostgresqlHandlers(db *driver.DB, a *config.AppConfig) *DBRepo {
 // AdminDashboard displays the dashboard
 func (repo *DBRepo) AdminDashboard(w http.ResponseWriter, r *http.Request) {
 
-	pending, healthy, warning, problem, err := repo.DB.GetAllServiceStatusCounts()
+	pending, healthy, warning, problem, unknown, err := repo.DB.GetAllServiceStatusCounts()
 	if err != nil {
 		log.Println(err)
 		return
@@ -60,6 +61,7 @@ func (repo *DBRepo) AdminDashboard(w http.ResponseWriter, r *http.Request) {
 	vars.Set("no_problem", problem)
 	vars.Set("no_pending", pending)
 	vars.Set("no_warning", warning)
+	vars.Set("no_unknown", unknown)
 
 	allHosts, err := repo.DB.AllHosts()
 	if err != nil {
@@ -74,14 +76,6 @@ func (repo *DBRepo) AdminDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Events displays the events page
-func (repo *DBRepo) Events(w http.ResponseWriter, r *http.Request) {
-	err := helpers.RenderPage(w, r, "events", nil, nil)
-	if err != nil {
-		printTemplateError(w, err)
-	}
-}
-
 // Settings displays the settings page
 func (repo *DBRepo) Settings(w http.ResponseWriter, r *http.Request) {
 	err := helpers.RenderPage(w, r, "settings", nil, nil)
@@ -111,6 +105,7 @@ func (repo *DBRepo) PostSettings(w http.ResponseWriter, r *http.Request) {
 	prefMap["notify_via_sms"] = r.Form.Get("notify_via_sms")
 	prefMap["notify_via_email"] = r.Form.Get("notify_via_email")
 	prefMap["sms_notify_number"] = r.Form.Get("sms_notify_number")
+	prefMap["sms_max_per_hour"] = r.Form.Get("sms_max_per_hour")
 
 	if r.Form.Get("sms_enabled") == "0" {
 		prefMap["notify_via_sms"] = "0"
@@ -173,8 +168,16 @@ func (repo *DBRepo) Host(w http.ResponseWriter, r *http.Request) {
 		h = host
 	}
 
+	//Default checker settings per service, used to prefill empty settings
+	defaults := make(map[int]string)
+	for _, hs := range h.HostServices {
+		defaults[hs.ID] = checkers.DefaultSettingsJSON(hs.Service.ServiceName)
+	}
+
 	vars := make(jet.VarMap)
 	vars.Set("host", h)
+	vars.Set("defaultSettings", defaults)
+	vars.Set("heartbeatURLs", repo.heartbeatURLs(h))
 
 	err := helpers.RenderPage(w, r, "host", vars, nil)
 	if err != nil {
@@ -413,6 +416,15 @@ func (repo *DBRepo) ToggleServiceForHost(w http.ResponseWriter, r *http.Request)
 		resp.OK = false
 	}
 
+	if resp.OK {
+		repo.logServiceToggled(hostID, serviceID, active)
+	}
+
+	//A retry still waiting would check, and store a result for, a service that is now off
+	if resp.OK && active == 0 {
+		repo.cancelServiceRetry(hostID, serviceID)
+	}
+
 	//Return JSON response
 	out, _ := json.MarshalIndent(resp, "", "  ")
 	w.Header().Set("Content-Type", "application/json")
@@ -420,6 +432,95 @@ func (repo *DBRepo) ToggleServiceForHost(w http.ResponseWriter, r *http.Request)
 
 }
 
+// UpdateServiceSettings validates and stores the checker settings for a host service
+func (repo *DBRepo) UpdateServiceSettings(w http.ResponseWriter, r *http.Request) {
+	// Parse incoming form values
+	err := r.ParseForm()
+	if err != nil {
+		log.Println(err)
+	}
+
+	var resp jsonResp
+	resp.OK = true
+
+	hostServiceID, _ := strconv.Atoi(r.Form.Get("host_service_id"))
+	settings := r.Form.Get("settings")
+	warning, _ := strconv.Atoi(r.Form.Get("warning_threshold"))
+	critical, _ := strconv.Atoi(r.Form.Get("critical_threshold"))
+	maxRetries, _ := strconv.Atoi(r.Form.Get("max_retries"))
+	retryInterval, _ := strconv.Atoi(r.Form.Get("retry_interval"))
+	flapDetection, _ := strconv.Atoi(r.Form.Get("flap_detection"))
+	addressFamily := r.Form.Get("address_family")
+	if addressFamily == "" {
+		addressFamily = checkers.FamilyAuto
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(hostServiceID)
+	if err != nil {
+		log.Println(err)
+		resp.OK = false
+		resp.Message = "Host service not found"
+	}
+
+	if resp.OK && (warning < 0 || critical < 0) {
+		resp.OK = false
+		resp.Message = "Response time thresholds cannot be negative"
+	}
+	if resp.OK && warning > 0 && critical > 0 && critical < warning {
+		resp.OK = false
+		resp.Message = "The critical response time threshold must not be lower than the warning threshold"
+	}
+	if resp.OK && (maxRetries < 0 || retryInterval < 1) {
+		resp.OK = false
+		resp.Message = "Retries cannot be negative and the retry interval must be at least one second"
+	}
+	if resp.OK && !checkers.ValidFamily(addressFamily) {
+		resp.OK = false
+		resp.Message = "Unknown address family"
+	}
+
+	//Let the checker for this service type reject settings it cannot use
+	if resp.OK {
+		err = checkers.ValidateSettings(hs.Service.ServiceName, settings)
+		if err != nil {
+			resp.OK = false
+			resp.Message = err.Error()
+		}
+	}
+
+	//Passwords are only ever stored encrypted
+	if resp.OK {
+		settings, err = checkers.SealSettings(settings)
+		if err != nil {
+			resp.OK = false
+			resp.Message = err.Error()
+		}
+	}
+
+	if resp.OK {
+		err = repo.DB.UpdateHostServiceSettings(hs.ID, settings)
+		if err == nil {
+			err = repo.DB.UpdateHostServiceThresholds(hs.ID, warning, critical)
+		}
+		if err == nil {
+			err = repo.DB.UpdateHostServiceRetries(hs.ID, maxRetries, retryInterval, flapDetection)
+		}
+		if err == nil {
+			err = repo.DB.UpdateHostServiceAddressFamily(hs.ID, addressFamily)
+		}
+		if err != nil {
+			log.Println(err)
+			resp.OK = false
+			resp.Message = "Could not save settings"
+		}
+	}
+
+	//Return JSON response
+	out, _ := json.MarshalIndent(resp, "", "  ")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
 // SetSystemPref updates a system preference in the DB and updates the
 // in-memory preference map
 func (repo *DBRepo) SetSystemPref(w http.ResponseWriter, r *http.Request) {
@@ -484,6 +585,11 @@ func (repo *DBRepo) ToggleMonitoring(w http.ResponseWriter, r *http.Request) {
 		//Stop scheduler
 		repo.App.Scheduler.Stop()
 
+		repo.logEvent(models.Event{
+			EventType: models.EventMonitoringStopped,
+			Message:   "Monitoring was turned off",
+		})
+
 		//Prepare websocket message data
 		data := make(map[string]string)
 		data["message"] = "Monitoring is off!"
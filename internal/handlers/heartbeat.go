@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"vigilate/internal/checkers"
+	"vigilate/internal/models"
+
+	"github.com/go-chi/chi"
+)
+
+//Package handlers contains the heartbeat endpoints that cron jobs and other
+//batch work ping: /heartbeat/{token} when a run succeeds, /heartbeat/{token}/start
+//when it begins and /heartbeat/{token}/fail when it fails. They are reached
+//without logging in; the secret token identifies the host service
+
+// Heartbeat records a ping from a monitored job
+func (repo *DBRepo) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	kind := chi.URLParam(r, "kind")
+
+	if kind != "" && kind != "start" && kind != "fail" {
+		http.NotFound(w, r)
+		return
+	}
+
+	hs, err := repo.DB.GetHostServiceByHeartbeatToken(token)
+	if err != nil || !strings.EqualFold(hs.Service.ServiceName, checkers.ServiceHeartbeat) {
+		http.NotFound(w, r)
+		return
+	}
+
+	now := time.Now()
+
+	if kind == "start" {
+		hs.PingStartedAt = now
+		err = repo.DB.UpdateHeartbeatPing(hs)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "could not record ping", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("OK\n"))
+		return
+	}
+
+	//A run that sent /start gets its duration recorded
+	var duration time.Duration
+	if hs.PingStartedAt.Year() > 1 && hs.PingStartedAt.After(hs.LastPingAt) {
+		duration = now.Sub(hs.PingStartedAt)
+	}
+
+	hs.LastPingAt = now
+	hs.LastPingStatus = checkers.PingSuccess
+	if kind == "fail" {
+		hs.LastPingStatus = checkers.PingFail
+	}
+	hs.PingStartedAt = time.Time{}
+
+	err = repo.DB.UpdateHeartbeatPing(hs)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "could not record ping", http.StatusInternalServerError)
+		return
+	}
+
+	//Inactive services keep their pings but are not checked
+	if hs.Active == 1 {
+		h, err := repo.DB.GetHostByID(hs.HostID)
+		if err != nil {
+			log.Println(err)
+		} else {
+			result := repo.testServiceForHost(h, hs)
+			if duration > 0 {
+				result.Message = fmt.Sprintf("%s, run took %s", result.Message, duration.Round(time.Millisecond))
+				result.Metrics = map[string]float64{"run_duration_ms": float64(duration.Milliseconds())}
+			}
+			_, err = repo.handleResult(h, &hs, result)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	w.Write([]byte("OK\n"))
+}
+
+// heartbeatURLs returns the ping URL of each heartbeat service of a host,
+// creating tokens for services that do not have one yet
+func (repo *DBRepo) heartbeatURLs(h models.Host) map[int]string {
+	urls := make(map[int]string)
+	siteURL := strings.TrimSuffix(app.PreferenceMap["site_url"], "/")
+
+	for _, hs := range h.HostServices {
+		if !strings.EqualFold(hs.Service.ServiceName, checkers.ServiceHeartbeat) {
+			continue
+		}
+
+		token := hs.HeartbeatToken
+		if token == "" {
+			b := make([]byte, 20)
+			if _, err := rand.Read(b); err != nil {
+				log.Println(err)
+				continue
+			}
+			token = hex.EncodeToString(b)
+
+			err := repo.DB.UpdateHeartbeatToken(hs.ID, token)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+		}
+
+		urls[hs.ID] = fmt.Sprintf("%s/heartbeat/%s", siteURL, token)
+	}
+
+	return urls
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"vigilate/internal/channeldata"
+	"vigilate/internal/checkers"
+	"vigilate/internal/helpers"
+	"vigilate/internal/models"
+	"vigilate/internal/sms"
+)
+
+//Package handlers contains the notifications sent when a host service changes
+//status: alerts when it goes into warning or problem, and a recovery notice
+//when it returns to healthy
+
+// defaultSMSPerHour is the per-number text limit when sms_max_per_hour is not set
+const defaultSMSPerHour = 5
+
+// smsLimiter stops a flapping service from sending a text on every change
+var smsLimiter = sms.NewRateLimiter(defaultSMSPerHour, time.Hour)
+
+// statusChange describes a host service moving from one status to another
+type statusChange struct {
+	Host      models.Host
+	Service   models.HostService
+	OldStatus string
+	NewStatus string
+	Message   string
+	CheckedAt time.Time
+	// Link is the host page, worked out before the change is handed to other goroutines
+	Link string
+}
+
+// recovered reports whether the service came back to healthy from a failure
+func (c statusChange) recovered() bool {
+	return c.NewStatus == checkers.StatusHealthy &&
+		(c.OldStatus == checkers.StatusWarning || c.OldStatus == checkers.StatusProblem || c.OldStatus == checkers.StatusUnknown)
+}
+
+// alerting reports whether the service went into a failure state
+func (c statusChange) alerting() bool {
+	return c.NewStatus == checkers.StatusWarning || c.NewStatus == checkers.StatusProblem ||
+		c.NewStatus == checkers.StatusUnknown
+}
+
+// hostLink returns a link to the host page, built from the site_url preference
+func hostLink(h models.Host) string {
+	siteURL := strings.TrimSuffix(app.PreferenceMap["site_url"], "/")
+	if siteURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/admin/host/%d", siteURL, h.ID)
+}
+
+// newStatusChange describes a host service moving from oldStatus to the result's status
+func newStatusChange(h models.Host, hs models.HostService, oldStatus string, result checkers.Result) statusChange {
+	return statusChange{
+		Host:      h,
+		Service:   hs,
+		OldStatus: oldStatus,
+		NewStatus: result.Status,
+		Message:   result.Message,
+		CheckedAt: time.Now(),
+		Link:      hostLink(h),
+	}
+}
+
+// updateNotifiedStatus records status as the one notifications know about and
+// returns the status they knew before, reporting whether it changed; while
+// the service is flapping nothing is passed on
+func updateNotifiedStatus(hs *models.HostService, status string) (string, bool) {
+	if hs.Flapping == 1 {
+		return "", false
+	}
+	last := hs.NotifiedStatus
+	hs.NotifiedStatus = status
+	return last, last != status
+}
+
+// notifyStatusChange sends the configured email and SMS notifications for a status change
+// Moving out of pending to healthy is not worth an email or text
+func (repo *DBRepo) notifyStatusChange(change statusChange) {
+	if !change.alerting() && !change.recovered() {
+		return
+	}
+
+	if app.PreferenceMap["notify_via_email"] == "1" {
+		sendStatusChangeEmail(change)
+	}
+
+	//Texts only go out for problems and recoveries from a problem
+	if app.PreferenceMap["sms_enabled"] == "1" && app.PreferenceMap["notify_via_sms"] == "1" {
+		if change.NewStatus == checkers.StatusProblem ||
+			(change.recovered() && change.OldStatus == checkers.StatusProblem) {
+			go sendStatusChangeSMS(change, readSMSPreferences())
+		}
+	}
+}
+
+// sendStatusChangeEmail queues an alert or recovery email for a status change
+func sendStatusChangeEmail(c statusChange) {
+	recovered := "0"
+	subject := fmt.Sprintf("%s: %s on %s", strings.ToUpper(c.NewStatus), c.Service.Service.ServiceName, c.Host.HostName)
+	if c.recovered() {
+		recovered = "1"
+		subject = fmt.Sprintf("RECOVERED: %s on %s", c.Service.Service.ServiceName, c.Host.HostName)
+	}
+
+	stringMap := make(map[string]string)
+	stringMap["host"] = c.Host.HostName
+	stringMap["service"] = c.Service.Service.ServiceName
+	stringMap["old_status"] = c.OldStatus
+	stringMap["new_status"] = c.NewStatus
+	stringMap["message"] = c.Message
+	stringMap["timestamp"] = c.CheckedAt.Format("2006-01-02 3:04:05 PM")
+	stringMap["link"] = c.Link
+	stringMap["recovered"] = recovered
+
+	mm := channeldata.MailData{
+		ToName:    app.PreferenceMap["notify_name"],
+		ToAddress: app.PreferenceMap["notify_email"],
+		Subject:   subject,
+		Template:  "alert.mail.tmpl",
+		StringMap: stringMap,
+	}
+
+	helpers.SendEmail(mm)
+}
+
+// smsMessage returns the short text sent for a status change
+func smsMessage(c statusChange) string {
+	if c.recovered() {
+		return fmt.Sprintf("Vigilate: RECOVERED %s on %s is healthy", c.Service.Service.ServiceName, c.Host.HostName)
+	}
+	return fmt.Sprintf("Vigilate: %s %s on %s - %s",
+		strings.ToUpper(c.NewStatus), c.Service.Service.ServiceName, c.Host.HostName, c.Message)
+}
+
+// smsPreferences are the SMS settings a text is sent with
+type smsPreferences struct {
+	Provider    string
+	APIURL      string
+	SID         string
+	AuthToken   string
+	PhoneNumber string
+	NotifyTo    string
+	PerHour     int
+}
+
+// readSMSPreferences copies the SMS settings out of the preference map, which
+// the settings page may be writing; call it before starting the goroutine that sends
+func readSMSPreferences() smsPreferences {
+	limit, err := strconv.Atoi(app.PreferenceMap["sms_max_per_hour"])
+	if err != nil {
+		limit = defaultSMSPerHour
+	}
+
+	return smsPreferences{
+		Provider:    app.PreferenceMap["sms_provider"],
+		APIURL:      app.PreferenceMap["twilio-api-url"],
+		SID:         app.PreferenceMap["twilio_sid"],
+		AuthToken:   app.PreferenceMap["twilio_auth_token"],
+		PhoneNumber: app.PreferenceMap["twilio_phone_number"],
+		NotifyTo:    app.PreferenceMap["sms_notify_number"],
+		PerHour:     limit,
+	}
+}
+
+// sendStatusChangeSMS texts every notify number that is still under its rate limit
+func sendStatusChangeSMS(c statusChange, p smsPreferences) {
+	if p.Provider != "twilio" {
+		log.Println("sms: unsupported provider", p.Provider)
+		return
+	}
+
+	smsLimiter.SetLimit(p.PerHour)
+
+	sender := sms.NewTwilio(p.APIURL, p.SID, p.AuthToken, p.PhoneNumber)
+	body := smsMessage(c)
+
+	for _, number := range strings.Split(p.NotifyTo, ",") {
+		number = strings.TrimSpace(number)
+		if number == "" {
+			continue
+		}
+
+		if !smsLimiter.Allow(number) {
+			log.Println("sms: rate limit reached for", number, "- not sending:", body)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := sender.Send(ctx, number, body)
+		cancel()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"testing"
+	"vigilate/internal/checkers"
+	"vigilate/internal/models"
+)
+
+func TestStatusChangeKind(t *testing.T) {
+	tests := []struct {
+		old, new            string
+		alerting, recovered bool
+	}{
+		{checkers.StatusPending, checkers.StatusHealthy, false, false},
+		{checkers.StatusHealthy, checkers.StatusWarning, true, false},
+		{checkers.StatusWarning, checkers.StatusProblem, true, false},
+		{checkers.StatusProblem, checkers.StatusHealthy, false, true},
+		{checkers.StatusWarning, checkers.StatusHealthy, false, true},
+		{checkers.StatusUnknown, checkers.StatusHealthy, false, true},
+	}
+
+	for _, tt := range tests {
+		c := statusChange{OldStatus: tt.old, NewStatus: tt.new}
+		if c.alerting() != tt.alerting {
+			t.Errorf("%s -> %s: alerting %v, want %v", tt.old, tt.new, c.alerting(), tt.alerting)
+		}
+		if c.recovered() != tt.recovered {
+			t.Errorf("%s -> %s: recovered %v, want %v", tt.old, tt.new, c.recovered(), tt.recovered)
+		}
+	}
+}
+
+func TestUpdateNotifiedStatus(t *testing.T) {
+	type step struct {
+		status   string
+		flapping int
+		from     string
+		notify   bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"plain changes", []step{
+			{checkers.StatusHealthy, 0, checkers.StatusPending, true},
+			{checkers.StatusHealthy, 0, checkers.StatusHealthy, false},
+			{checkers.StatusProblem, 0, checkers.StatusHealthy, true},
+		}},
+		{"recovers after flapping", []step{
+			{checkers.StatusProblem, 0, checkers.StatusPending, true},
+			{checkers.StatusHealthy, 1, "", false},
+			{checkers.StatusProblem, 1, "", false},
+			{checkers.StatusHealthy, 1, "", false},
+			{checkers.StatusHealthy, 0, checkers.StatusProblem, true},
+		}},
+		{"settles where it started", []step{
+			{checkers.StatusProblem, 0, checkers.StatusPending, true},
+			{checkers.StatusHealthy, 1, "", false},
+			{checkers.StatusProblem, 0, checkers.StatusProblem, false},
+		}},
+	}
+
+	for _, tt := range tests {
+		hs := &models.HostService{NotifiedStatus: checkers.StatusPending}
+		for i, s := range tt.steps {
+			hs.Flapping = s.flapping
+			from, notify := updateNotifiedStatus(hs, s.status)
+			if notify != s.notify || (notify && from != s.from) {
+				t.Errorf("%s, step %d: got %q, %t; want %q, %t", tt.name, i+1, from, notify, s.from, s.notify)
+			}
+		}
+	}
+
+	//The settled change is a recovery
+	c := statusChange{OldStatus: checkers.StatusProblem, NewStatus: checkers.StatusHealthy}
+	if !c.recovered() {
+		t.Error("settling healthy after a notified problem is not a recovery")
+	}
+}
+
+func TestSMSMessage(t *testing.T) {
+	hs := models.HostService{Service: models.Services{ServiceName: "HTTP"}}
+	h := models.Host{HostName: "web1"}
+
+	tests := []struct {
+		change statusChange
+		want   string
+	}{
+		{
+			statusChange{Host: h, Service: hs, OldStatus: checkers.StatusHealthy, NewStatus: checkers.StatusProblem, Message: "error connecting"},
+			"Vigilate: PROBLEM HTTP on web1 - error connecting",
+		},
+		{
+			statusChange{Host: h, Service: hs, OldStatus: checkers.StatusProblem, NewStatus: checkers.StatusHealthy, Message: "200 OK"},
+			"Vigilate: RECOVERED HTTP on web1 is healthy",
+		},
+	}
+	for _, tt := range tests {
+		if got := smsMessage(tt.change); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -6,20 +6,13 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
+	"vigilate/internal/checkers"
 	"vigilate/internal/models"
 
 	"github.com/go-chi/chi"
 )
 
-// Types of services
-const (
-	HTTP           = 1
-	HTTPS          = 2
-	SSLCertificate = 3
-)
-
 // JSON resp sent to client
 type jsonResp struct {
 	OK            bool      `json:"ok"`
@@ -41,37 +34,102 @@ func (repo *DBRepo) ScheduledCheck(hostServiceID int) {
 		log.Println(err)
 		return
 	}
+	//A retry can fire just as the service is turned off
+	if hs.Active == 0 {
+		return
+	}
 	h, err := repo.DB.GetHostByID(hs.HostID)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
-	newStatus, msg := repo.testServiceForHost(h, hs)
+	result := repo.testServiceForHost(h, hs)
+
+	//Record the outcome of every run, not only status changes
+	_, err = repo.handleResult(h, &hs, result)
+	if err != nil {
+		log.Println(err)
+	}
+}
 
-	if newStatus != hs.Status {
-		repo.updateHostServiceStatusCount(h, hs, newStatus, msg)
+// handleResult stores the outcome of a check on a host service: a problem is
+// only taken once retries confirm it, flapping is tracked, and a status change
+// is logged, broadcast and, unless the service is flapping, notified
+// It returns the result as applied to the host service
+func (repo *DBRepo) handleResult(h models.Host, hs *models.HostService, result checkers.Result) (checkers.Result, error) {
+	oldStatus := hs.Status
+
+	applied := result
+	if !repo.confirmFailure(h, hs, result) {
+		applied.Status = oldStatus
+		applied.Message = fmt.Sprintf("%s (failure %d of %d, retrying)", result.Message, hs.FailureCount, hs.MaxRetries+1)
 	}
+	applyResult(hs, applied)
 
-}
+	//The history keeps what each check saw, so soft failures count towards flapping
+	repo.recordCheckResult(*hs, result)
+	repo.detectFlapping(h, hs)
+	notifiedStatus, notify := updateNotifiedStatus(hs, applied.Status)
 
-func (repo *DBRepo) updateHostServiceStatusCount(h models.Host, hs models.HostService, newStatus, msg string) {
-	// if hostServiceStatusChanged {
-	// 	data := make(map[string]string)
-	// 	data["message"] = fmt.Sprintf("host services %s on %s has changed to %s", hs.Service.ServiceName, h.HostName, newStatus)
-	// 	repo.broadcastMessage("public-channel", "host-service-status-changed", data)
+	err := repo.DB.UpdateHostService(*hs)
+	if err != nil {
+		return applied, err
+	}
 
-	// }
+	if applied.Status != oldStatus {
+		repo.broadcastStatusChange(h, *hs, applied)
+		repo.logStatusChange(h, *hs, oldStatus, applied)
 
-	hs.Status = newStatus
+		//Webhooks get every status change, flapping or not; their own filters decide what to send
+		go repo.sendStatusChangeWebhooks(newStatusChange(h, *hs, oldStatus, applied))
+		repo.updateHostServiceStatusCount(applied)
+	}
+
+	//Email and text the change from what was last notified, which after
+	//flapping settles may differ from the status just before
+	if notify {
+		repo.notifyStatusChange(newStatusChange(h, *hs, notifiedStatus, applied))
+	}
+
+	return applied, nil
+}
+
+// applyResult copies the outcome of a check onto the host service record
+func applyResult(hs *models.HostService, result checkers.Result) {
+	hs.Status = result.Status
+	hs.LastMessage = result.Message
 	hs.LastCheck = time.Now()
-	err := repo.DB.UpdateHostService(hs)
+	hs.ResponseTime = int(result.Latency.Milliseconds())
+	hs.UpdatedAt = time.Now()
+	if !result.CertExpiry.IsZero() {
+		hs.CertExpiry = result.CertExpiry
+	}
+}
+
+// recordCheckResult adds a check execution to the check result history
+func (repo *DBRepo) recordCheckResult(hs models.HostService, result checkers.Result) {
+	cr := models.CheckResult{
+		HostServiceID: hs.ID,
+		Status:        result.Status,
+		Message:       result.Message,
+		Latency:       result.Latency,
+		Metrics:       result.Metrics,
+		CheckedAt:     hs.LastCheck,
+	}
+	if result.Err != nil {
+		cr.Error = result.Err.Error()
+	}
+
+	_, err := repo.DB.InsertCheckResult(cr)
 	if err != nil {
 		log.Println(err)
-		return
 	}
+}
 
-	pending, healthy, warning, problem, err := repo.DB.GetAllServiceStatusCounts()
+// updateHostServiceStatusCount broadcasts the current service counts per status
+func (repo *DBRepo) updateHostServiceStatusCount(result checkers.Result) {
+	pending, healthy, warning, problem, unknown, err := repo.DB.GetAllServiceStatusCounts()
 	if err != nil {
 		log.Println(err)
 		return
@@ -82,9 +140,10 @@ func (repo *DBRepo) updateHostServiceStatusCount(h models.Host, hs models.HostSe
 	data["pending_count"] = strconv.Itoa(pending)
 	data["problem_count"] = strconv.Itoa(problem)
 	data["warning_count"] = strconv.Itoa(warning)
+	data["unknown_count"] = strconv.Itoa(unknown)
 	repo.broadcastMessage("public-channel", "host-service-count-changed", data)
 
-	log.Println("New status is", newStatus, "and msg is ", msg)
+	log.Println("New status is", result.Status, "and msg is ", result.Message)
 }
 
 func (repop *DBRepo) broadcastMessage(channel, messageType string, data map[string]string) {
@@ -119,18 +178,14 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//Run the actual service test based on service type
-	msg, newStatus := repo.testServiceForHost(h, hs)
+	result := repo.testServiceForHost(h, hs)
 
-	//update the host service in the database with status (if changed) and last check
-	hs.Status = newStatus
-	hs.LastCheck = time.Now()
-	hs.UpdatedAt = time.Now()
-	err = repo.DB.UpdateHostService(hs)
+	//update the host service in the database, logging and broadcasting any status change
+	result, err = repo.handleResult(h, &hs, result)
 	if err != nil {
 		log.Println(err)
 		okay = false
 	}
-	//broadcast service status changed event
 
 	var resp jsonResp
 
@@ -138,12 +193,12 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, r *http.Request) {
 	if okay {
 		resp = jsonResp{
 			OK:            true,
-			Message:       msg,
+			Message:       result.Message,
 			ServiceID:     hs.ServiceID,
 			HostServiceID: hs.ID,
 			HostID:        hs.HostID,
 			OldStatus:     oldStatus,
-			NewStatus:     newStatus,
+			NewStatus:     result.Status,
 			LastCheck:     time.Now(),
 		}
 	} else {
@@ -157,59 +212,24 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, r *http.Request) {
 	w.Write(out)
 }
 
-// testServiceForHost determines which test to run depending on service type
-func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostService) (string, string) {
-	var msg, newStatus string
-
-	switch hs.ServiceID {
-	case HTTP:
-		//Run HTTP test for this host
-		msg, newStatus = testHTTPForHost(h.URL)
-
-	}
-
-	//TODO broadcast to clients if appropriate
-	if hs.Status != newStatus {
-		data := make(map[string]string)
-		data["host_id"] = strconv.Itoa(hs.HostID)
-		data["host_service_id"] = strconv.Itoa(hs.ID)
-		data["host_name"] = h.HostName
-		data["service_name"] = hs.Service.ServiceName
-		data["icon"] = hs.Service.Icon
-		data["status"] = newStatus
-		data["message"] = fmt.Sprintf("%s on %s reports %s", hs.Service.ServiceName, h.HostName, newStatus)
-		data["last_check"] = time.Now().Format("2006-01-02 3:04:06 PM")
-
-		repo.broadcastMessage("public-channel", "host-service-status-changed", data)
-	}
-
-	//TODO send email/sms
-
-	return msg, newStatus
+// testServiceForHost runs the checker registered for the host service's type
+func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostService) checkers.Result {
+	return checkers.Run(checkers.Target{Host: h, HostService: hs})
 }
 
-// testHTTPForHost performs actual HTTP GET request to check if host is reachable
-func testHTTPForHost(url string) (string, string) {
-	//Normalize URL : remove trailing slash
-	if strings.HasSuffix(url, "/") {
-		url = strings.TrimSuffix(url, "/")
-	}
-
-	//Convert https to http
-	url = strings.Replace(url, "https://", "http://", -1)
-
-	//Make GET request
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Sprintf("%s - %s", url, "error connecting"), "problem"
-	}
-	defer resp.Body.Close()
-
-	//Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Sprintf("%s - %s", url, resp.Status), "problem"
-	}
-
-	//Status 200 = healthy
-	return fmt.Sprintf("%s - %s", url, resp.Status), "healthy"
+// broadcastStatusChange tells clients that a host service changed status
+func (repo *DBRepo) broadcastStatusChange(h models.Host, hs models.HostService, result checkers.Result) {
+	data := make(map[string]string)
+	data["host_id"] = strconv.Itoa(hs.HostID)
+	data["host_service_id"] = strconv.Itoa(hs.ID)
+	data["host_name"] = h.HostName
+	data["service_name"] = hs.Service.ServiceName
+	data["icon"] = hs.Service.Icon
+	data["status"] = result.Status
+	data["message"] = fmt.Sprintf("%s on %s reports %s", hs.Service.ServiceName, h.HostName, result.Status)
+	data["last_message"] = result.Message
+	data["response_time"] = strconv.FormatInt(result.Latency.Milliseconds(), 10)
+	data["last_check"] = time.Now().Format("2006-01-02 3:04:06 PM")
+
+	repo.broadcastMessage("public-channel", "host-service-status-changed", data)
 }
@@ -4,12 +4,14 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
-
-	"github.com/pusher/pusher-http-go"
+	"strings"
+	"vigilate/internal/broadcast"
 )
 
-// PusherAuth handles authentication for Pusher presence channels
+// PusherAuth signs subscriptions to private and presence channels for the
+// logged in user; pusher-js calls it for both the built-in hub and Pusher
 func (repo *DBRepo) PusherAuth(w http.ResponseWriter, r *http.Request) {
 	//Get the current user's ID from session
 	userID := repo.App.Session.GetInt(r.Context(), "userID")
@@ -20,19 +22,32 @@ func (repo *DBRepo) PusherAuth(w http.ResponseWriter, r *http.Request) {
 	// Read request body containing Pusher auth params
 	params, _ := io.ReadAll(r.Body)
 
-	// Prepare presence data with user info
-	presenceData := pusher.MemberData{
-		UserID: strconv.Itoa(userID),
-		UserInfo: map[string]string{
-			"name": u.FirstName,
-			"id":   strconv.Itoa(userID),
-		},
+	form, err := url.ParseQuery(string(params))
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
 	}
 
-	// Authenticate the user for the presence channel
-	response, err := app.WsClient.AuthenticatePresenceChannel(params, presenceData)
+	var response []byte
+	if strings.HasPrefix(form.Get("channel_name"), broadcast.PresencePrefix) {
+		// Prepare presence data with user info
+		presenceData := broadcast.MemberData{
+			UserID: strconv.Itoa(userID),
+			UserInfo: map[string]string{
+				"name": u.FirstName,
+				"id":   strconv.Itoa(userID),
+			},
+		}
+
+		// Authenticate the user for the presence channel
+		response, err = app.WsClient.AuthenticatePresenceChannel(params, presenceData)
+	} else {
+		response, err = app.WsClient.AuthenticatePrivateChannel(params)
+	}
 	if err != nil {
 		log.Println(err)
+		ClientError(w, r, http.StatusForbidden)
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"log"
 	"strconv"
 	"time"
+	"vigilate/internal/models"
 )
 
 // job represents a monitoring task for a specific service
@@ -33,6 +34,11 @@ func (repo *DBRepo) StartMonitoring() {
 			log.Println(err)
 		}
 
+		repo.logEvent(models.Event{
+			EventType: models.EventMonitoringStarted,
+			Message:   "Monitoring was turned on",
+		})
+
 		//Get all the services that should be monitored from the database
 		servicesToMonitor, err := repo.DB.GetServicesToMonitor()
 		if err != nil {
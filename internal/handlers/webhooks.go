@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vigilate/internal/helpers"
+	"vigilate/internal/models"
+	"vigilate/internal/webhooks"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/go-chi/chi"
+)
+
+//Package handlers contains the outbound webhooks: the admin pages used to
+//manage them and view their delivery log, and the signed JSON payload posted
+//to each enabled webhook when a host service changes status
+
+// webhookDeliveriesShown is the number of delivery attempts shown on the webhook page
+const webhookDeliveriesShown = 50
+
+// webhookSender delivers webhook payloads with the default retry policy
+var webhookSender = webhooks.NewSender()
+
+// webhookPayload is the JSON body posted to webhooks on a status change
+type webhookPayload struct {
+	Event         string    `json:"event"`
+	HostID        int       `json:"host_id"`
+	Host          string    `json:"host"`
+	HostServiceID int       `json:"host_service_id"`
+	ServiceName   string    `json:"service_name"`
+	OldStatus     string    `json:"old_status"`
+	NewStatus     string    `json:"new_status"`
+	Message       string    `json:"message"`
+	LastCheck     time.Time `json:"last_check"`
+	Link          string    `json:"link"`
+}
+
+// AllWebhooks lists the configured webhooks
+func (repo *DBRepo) AllWebhooks(w http.ResponseWriter, r *http.Request) {
+	vars := make(jet.VarMap)
+
+	wh, err := repo.DB.AllWebhooks()
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	vars.Set("webhooks", wh)
+
+	err = helpers.RenderPage(w, r, "webhooks", vars, nil)
+	if err != nil {
+		printTemplateError(w, err)
+	}
+}
+
+// OneWebhook shows the webhook form and its recent deliveries; id 0 is a new webhook
+func (repo *DBRepo) OneWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Println(err)
+	}
+
+	vars := make(jet.VarMap)
+
+	var wh models.Webhook
+	var deliveries []models.WebhookDelivery
+
+	if id > 0 {
+		wh, err = repo.DB.GetWebhookByID(id)
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+
+		deliveries, err = repo.DB.GetWebhookDeliveries(id, webhookDeliveriesShown)
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+	} else {
+		wh.Active = 1
+	}
+
+	vars.Set("webhook", wh)
+	vars.Set("deliveries", deliveries)
+
+	err = helpers.RenderPage(w, r, "webhook", vars, nil)
+	if err != nil {
+		printTemplateError(w, err)
+	}
+}
+
+// PostOneWebhook inserts or updates a webhook
+func (repo *DBRepo) PostOneWebhook(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Println(err)
+	}
+
+	var wh models.Webhook
+	if id > 0 {
+		wh, err = repo.DB.GetWebhookByID(id)
+		if err != nil {
+			ServerError(w, r, err)
+			return
+		}
+	}
+
+	wh.Name = r.Form.Get("name")
+	wh.URL = strings.TrimSpace(r.Form.Get("url"))
+	wh.Secret = r.Form.Get("secret")
+	wh.Events = r.Form.Get("events")
+	wh.Active, _ = strconv.Atoi(r.Form.Get("active"))
+
+	//Deliveries can only be posted to an absolute http or https URL
+	if err := webhooks.ValidateURL(wh.URL); err != nil {
+		repo.App.Session.Put(r.Context(), "error", err.Error())
+		http.Redirect(w, r, fmt.Sprintf("/admin/webhook/%d", id), http.StatusSeeOther)
+		return
+	}
+
+	if id > 0 {
+		err = repo.DB.UpdateWebhook(wh)
+	} else {
+		_, err = repo.DB.InsertWebhook(wh)
+	}
+	if err != nil {
+		ServerError(w, r, err)
+		return
+	}
+
+	repo.App.Session.Put(r.Context(), "flash", "Changes saved")
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func (repo *DBRepo) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	_ = repo.DB.DeleteWebhook(id)
+	repo.App.Session.Put(r.Context(), "flash", "Webhook deleted")
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
+
+// sendStatusChangeWebhooks posts a status change to every enabled webhook whose
+// event filter matches the new status, logging each delivery attempt
+func (repo *DBRepo) sendStatusChangeWebhooks(c statusChange) {
+	all, err := repo.DB.AllWebhooks()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:         models.EventStatusChanged,
+		HostID:        c.Host.ID,
+		Host:          c.Host.HostName,
+		HostServiceID: c.Service.ID,
+		ServiceName:   c.Service.Service.ServiceName,
+		OldStatus:     c.OldStatus,
+		NewStatus:     c.NewStatus,
+		Message:       c.Message,
+		LastCheck:     c.CheckedAt,
+		Link:          c.Link,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, wh := range all {
+		if wh.Active != 1 || !webhooks.Matches(wh.Events, c.NewStatus) {
+			continue
+		}
+		go repo.deliverWebhook(wh, models.EventStatusChanged, body)
+	}
+}
+
+// deliverWebhook sends one payload to a webhook, retrying with backoff
+func (repo *DBRepo) deliverWebhook(wh models.Webhook, event string, body []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	err := webhookSender.Deliver(ctx, wh.URL, wh.Secret, event, body, func(a webhooks.Attempt) {
+		d := models.WebhookDelivery{
+			WebhookID:  wh.ID,
+			Event:      event,
+			Payload:    string(body),
+			Attempt:    a.Number,
+			StatusCode: a.StatusCode,
+			Duration:   a.Duration,
+		}
+		if a.Success() {
+			d.Success = 1
+		} else {
+			d.Error = a.Err.Error()
+		}
+
+		_, err := repo.DB.InsertWebhookDelivery(d)
+		if err != nil {
+			log.Println(err)
+		}
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
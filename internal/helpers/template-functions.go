@@ -20,6 +20,11 @@ func addTemplateFunctions() {
 	views.AddGlobal("dateAfterYearOne", func(t time.Time) bool {
 		return DateAfterY1(t)
 	})
+
+	//Add a global template function returning whole days until a time
+	views.AddGlobal("daysUntil", func(t time.Time) int {
+		return DaysUntil(t)
+	})
 }
 
 // HumanDate formats a time in YYYY-MM-DD format
@@ -40,3 +45,8 @@ func DateAfterY1(t time.Time) bool {
 	yearOne := time.Date(0001, 11, 17, 20, 34, 58, 651387237, time.UTC)
 	return t.After(yearOne)
 }
+
+// DaysUntil returns the number of whole days from now until t (negative once t has passed)
+func DaysUntil(t time.Time) int {
+	return int(time.Until(t).Hours() / 24)
+}
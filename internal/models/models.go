@@ -81,10 +81,130 @@ type HostService struct {
 	ScheduleUnit   string
 	Status         string
 	LastCheck      time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Service        Services
-	HostName       string
+	LastMessage    string
+	Settings       string
+	CertExpiry     time.Time
+	// Response time thresholds and the last measured response time, in milliseconds
+	// A threshold of 0 is not checked
+	WarningThreshold  int
+	CriticalThreshold int
+	ResponseTime      int
+	// MaxRetries is how many times a failed check is retried, RetryInterval seconds
+	// apart, before the service is put in problem; FailureCount counts consecutive failures
+	MaxRetries    int
+	RetryInterval int
+	FailureCount  int
+	// Flapping is set while flap detection sees the status changing too often
+	FlapDetection int
+	Flapping      int
+	// NotifiedStatus is the status last passed on to notifications, which
+	// changes while flapping are not
+	NotifiedStatus string
+	// Heartbeat services are pinged by the job they watch at /heartbeat/{HeartbeatToken}
+	// LastPingStatus is success or fail; PingStartedAt is set by a /start ping
+	HeartbeatToken string
+	LastPingAt     time.Time
+	LastPingStatus string
+	PingStartedAt  time.Time
+	// ActivatedAt is when the service was last switched on, which a heartbeat
+	// that has never been pinged is late from
+	ActivatedAt time.Time
+	// AddressFamily is auto, ipv4, ipv6 or both (each family checked separately)
+	AddressFamily string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Service       Services
+	HostName      string
+}
+
+// CheckResult model, one row per check execution
+type CheckResult struct {
+	ID            int
+	HostServiceID int
+	Status        string
+	Message       string
+	Latency       time.Duration
+	Error         string
+	Metrics       map[string]float64
+	CheckedAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Event types written to the event log
+const (
+	EventStatusChanged     = "status-changed"
+	EventServiceEnabled    = "service-enabled"
+	EventServiceDisabled   = "service-disabled"
+	EventMonitoringStarted = "monitoring-started"
+	EventMonitoringStopped = "monitoring-stopped"
+	EventCheckRetry        = "check-retry"
+	EventFlappingStarted   = "flapping-started"
+	EventFlappingStopped   = "flapping-stopped"
+)
+
+// EventTypes lists all event types, in display order
+var EventTypes = []string{
+	EventStatusChanged,
+	EventServiceEnabled,
+	EventServiceDisabled,
+	EventMonitoringStarted,
+	EventMonitoringStopped,
+	EventCheckRetry,
+	EventFlappingStarted,
+	EventFlappingStopped,
+}
+
+// Event model, an entry in the event log
+// Host and service names are copied so events outlive the records they mention
+type Event struct {
+	ID            int
+	EventType     string
+	HostServiceID int
+	HostID        int
+	ServiceID     int
+	HostName      string
+	ServiceName   string
+	Message       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// EventFilter narrows down an event log query; zero values match everything
+type EventFilter struct {
+	HostID    int
+	ServiceID int
+	EventType string
+	From      time.Time
+	To        time.Time
+}
+
+// Webhook model, an outbound endpoint notified of status changes
+// Events is a comma separated list of new statuses to send; empty sends all
+type Webhook struct {
+	ID        int
+	Name      string
+	URL       string
+	Secret    string
+	Events    string
+	Active    int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery model, one attempt to deliver a payload to a webhook
+type WebhookDelivery struct {
+	ID         int
+	WebhookID  int
+	Event      string
+	Payload    string
+	Attempt    int
+	StatusCode int
+	Success    int
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 // Schedule model
@@ -0,0 +1,166 @@
+package dbrepo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+	"vigilate/internal/models"
+)
+
+//Package dbrepo provides the PostgreSQL implementation for the check result
+//history: one row per check execution, queried per host service and time range
+
+// InsertCheckResult records a single check execution and returns its ID
+func (m *postgresDBRepo) InsertCheckResult(cr models.CheckResult) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	metrics := []byte("{}")
+	if len(cr.Metrics) > 0 {
+		b, err := json.Marshal(cr.Metrics)
+		if err != nil {
+			return 0, err
+		}
+		metrics = b
+	}
+
+	stmt := `
+		insert into check_results (host_service_id, status, message, latency_ms, error,
+			metrics, checked_at, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		returning id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		cr.HostServiceID,
+		cr.Status,
+		cr.Message,
+		cr.Latency.Milliseconds(),
+		cr.Error,
+		string(metrics),
+		cr.CheckedAt,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetCheckResults returns one page of check results for a host service, newest first,
+// along with the total number of results in the time range
+// A zero from or to leaves that end of the range open
+func (m *postgresDBRepo) GetCheckResults(hostServiceID int, from, to time.Time, limit, offset int) ([]models.CheckResult, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if to.IsZero() {
+		to = time.Now().Add(24 * time.Hour)
+	}
+
+	// count all matching rows so callers can build pagination
+	countQuery := `
+		select count(id) from check_results
+		where host_service_id = $1 and checked_at >= $2 and checked_at <= $3`
+
+	var total int
+	err := m.DB.QueryRowContext(ctx, countQuery, hostServiceID, from, to).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		select id, host_service_id, status, message, latency_ms, error,
+			metrics, checked_at, created_at, updated_at
+		from check_results
+		where host_service_id = $1 and checked_at >= $2 and checked_at <= $3
+		order by checked_at desc
+		limit $4 offset $5`
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID, from, to, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []models.CheckResult
+
+	for rows.Next() {
+		var cr models.CheckResult
+		var latencyMS int64
+		var metrics string
+
+		err := rows.Scan(
+			&cr.ID,
+			&cr.HostServiceID,
+			&cr.Status,
+			&cr.Message,
+			&latencyMS,
+			&cr.Error,
+			&metrics,
+			&cr.CheckedAt,
+			&cr.CreatedAt,
+			&cr.UpdatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, 0, err
+		}
+		cr.Latency = time.Duration(latencyMS) * time.Millisecond
+		if metrics != "" && metrics != "{}" {
+			if err := json.Unmarshal([]byte(metrics), &cr.Metrics); err != nil {
+				log.Println(err)
+			}
+		}
+
+		results = append(results, cr)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// GetRecentCheckStatuses returns the statuses of the last limit check results
+// for a host service, newest first
+func (m *postgresDBRepo) GetRecentCheckStatuses(hostServiceID, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select status from check_results
+		where host_service_id = $1
+		order by checked_at desc
+		limit $2`
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []string
+
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,126 @@
+package dbrepo
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+	"vigilate/internal/models"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepo connects to the database in VIGILATE_TEST_DSN and gives the test a
+// temporary check_results table, which shadows any real one for its connection
+func testRepo(t *testing.T) *postgresDBRepo {
+	t.Helper()
+
+	dsn := os.Getenv("VIGILATE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("VIGILATE_TEST_DSN is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	//Temporary tables belong to one connection
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		create temporary table check_results (
+			id serial primary key,
+			host_service_id integer not null,
+			status varchar(20) not null,
+			message text not null default '',
+			latency_ms bigint not null default 0,
+			error text not null default '',
+			metrics text not null default '{}',
+			checked_at timestamp not null,
+			created_at timestamp not null,
+			updated_at timestamp not null
+		)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &postgresDBRepo{DB: db}
+}
+
+func TestCheckResults(t *testing.T) {
+	m := testRepo(t)
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	//Five results a minute apart for host service 1, and one for host service 2
+	statuses := []string{"healthy", "healthy", "warning", "problem", "healthy"}
+	for i, status := range statuses {
+		cr := models.CheckResult{
+			HostServiceID: 1,
+			Status:        status,
+			Message:       "check " + status,
+			Latency:       time.Duration(i+1) * 10 * time.Millisecond,
+			CheckedAt:     base.Add(time.Duration(i) * time.Minute),
+		}
+		if i == 4 {
+			cr.Metrics = map[string]float64{"rtt_avg_ms": 1.5}
+		}
+		if _, err := m.InsertCheckResult(cr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.InsertCheckResult(models.CheckResult{HostServiceID: 2, Status: "problem", CheckedAt: base}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		from, to      time.Time
+		limit, offset int
+		want          []string
+		total         int
+	}{
+		{"all", time.Time{}, time.Time{}, 10, 0, []string{"healthy", "problem", "warning", "healthy", "healthy"}, 5},
+		{"first page", time.Time{}, time.Time{}, 2, 0, []string{"healthy", "problem"}, 5},
+		{"last page", time.Time{}, time.Time{}, 2, 4, []string{"healthy"}, 5},
+		{"past the end", time.Time{}, time.Time{}, 2, 6, nil, 5},
+		{"from", base.Add(3 * time.Minute), time.Time{}, 10, 0, []string{"healthy", "problem"}, 2},
+		{"range", base.Add(time.Minute), base.Add(3 * time.Minute), 10, 0, []string{"problem", "warning", "healthy"}, 3},
+		{"range paged", base.Add(time.Minute), base.Add(3 * time.Minute), 1, 1, []string{"warning"}, 3},
+		{"before any", time.Time{}, base.Add(-time.Minute), 10, 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := m.GetCheckResults(1, tt.from, tt.to, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, cr := range results {
+				got = append(got, cr.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) || total != tt.total {
+				t.Errorf("got %v of %d, want %v of %d", got, total, tt.want, tt.total)
+			}
+		})
+	}
+
+	//The newest result comes back as it was stored
+	results, _, err := m.GetCheckResults(1, time.Time{}, time.Time{}, 1, 0)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("got %v, %v; want one result", results, err)
+	}
+	cr := results[0]
+	if cr.HostServiceID != 1 || cr.Message != "check healthy" || cr.Latency != 50*time.Millisecond ||
+		!cr.CheckedAt.Equal(base.Add(4*time.Minute)) || cr.Metrics["rtt_avg_ms"] != 1.5 {
+		t.Errorf("got %+v", cr)
+	}
+
+	recent, err := m.GetRecentCheckStatuses(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"healthy", "problem", "warning"}; !reflect.DeepEqual(recent, want) {
+		t.Errorf("recent statuses %v, want %v", recent, want)
+	}
+}
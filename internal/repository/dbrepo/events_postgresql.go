@@ -0,0 +1,143 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"vigilate/internal/models"
+)
+
+//Package dbrepo provides the PostgreSQL implementation of the event log:
+//status changes, services toggled on or off, and monitoring started or stopped
+
+// InsertEvent writes an entry to the event log and returns its ID
+func (m *postgresDBRepo) InsertEvent(e models.Event) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `
+		insert into events (event_type, host_service_id, host_id, service_id,
+			host_name, service_name, message, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		returning id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		e.EventType,
+		nullInt(e.HostServiceID),
+		nullInt(e.HostID),
+		nullInt(e.ServiceID),
+		e.HostName,
+		e.ServiceName,
+		e.Message,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetEvents returns one page of events matching the filter, newest first,
+// along with the total number of matching events
+func (m *postgresDBRepo) GetEvents(filter models.EventFilter, limit, offset int) ([]models.Event, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// build the where clause from the filter values that are set
+	var where []string
+	var args []interface{}
+
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.HostID > 0 {
+		addCondition("host_id = $%d", filter.HostID)
+	}
+	if filter.ServiceID > 0 {
+		addCondition("service_id = $%d", filter.ServiceID)
+	}
+	if filter.EventType != "" {
+		addCondition("event_type = $%d", filter.EventType)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at <= $%d", filter.To)
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "where " + strings.Join(where, " and ")
+	}
+
+	var total int
+	err := m.DB.QueryRowContext(ctx, "select count(id) from events "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		select id, event_type, host_service_id, host_id, service_id,
+			host_name, service_name, message, created_at, updated_at
+		from events
+		%s
+		order by created_at desc, id desc
+		limit $%d offset $%d`, whereClause, len(args)+1, len(args)+2)
+
+	rows, err := m.DB.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+
+	for rows.Next() {
+		var e models.Event
+		var hostServiceID, hostID, serviceID sql.NullInt64
+
+		err := rows.Scan(
+			&e.ID,
+			&e.EventType,
+			&hostServiceID,
+			&hostID,
+			&serviceID,
+			&e.HostName,
+			&e.ServiceName,
+			&e.Message,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, 0, err
+		}
+		e.HostServiceID = int(hostServiceID.Int64)
+		e.HostID = int(hostID.Int64)
+		e.ServiceID = int(serviceID.Int64)
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// nullInt stores zero IDs as NULL
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i > 0}
+}
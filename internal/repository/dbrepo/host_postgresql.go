@@ -53,7 +53,18 @@ returning id`
 
 	stmt := `
 					insert into host_services (host_id, service_id, active, schedule_number, schedule_unit, 
-					status, created_at, updated_at) values ($1, 1, 1, 3, 'm', 'pending', $2, $3)
+					status, activated_at, created_at, updated_at) values ($1, 1, 1, 3, 'm', 'pending', $2, $3, $4)
+	`
+	_, err = m.DB.ExecContext(ctx, stmt, newID, time.Now(), time.Now(), time.Now())
+	if err != nil {
+		return newID, err
+	}
+
+	//Add every other service switched off, so it can be turned on from the host page
+	stmt = `
+					insert into host_services (host_id, service_id, active, schedule_number, schedule_unit,
+					status, created_at, updated_at)
+					select $1, id, 0, 3, 'm', 'pending', $2, $3 from services where id <> 1
 	`
 	_, err = m.DB.ExecContext(ctx, stmt, newID, time.Now(), time.Now())
 	if err != nil {
@@ -112,7 +123,10 @@ func (m *postgresDBRepo) GetHostByID(id int) (models.Host, error) {
 	//Query to retieve all services associated with the host
 	query = `select
 	              hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
-	              hs.last_check, hs.status, hs.created_at, hs.updated_at,
+	              hs.last_check, hs.status, hs.last_message, hs.settings, hs.cert_expiry, hs.warning_threshold_ms, hs.critical_threshold_ms,
+	              hs.response_time_ms, hs.max_retries, hs.retry_interval, hs.failure_count,
+		hs.flap_detection, hs.flapping, hs.notified_status, hs.address_family, hs.heartbeat_token, hs.last_ping_at, hs.last_ping_status,
+		hs.ping_started_at, hs.activated_at, hs.created_at, hs.updated_at,
 								s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at
 					  from
 						    host_services hs
@@ -141,6 +155,24 @@ func (m *postgresDBRepo) GetHostByID(id int) (models.Host, error) {
 			&hs.ScheduleUnit,
 			&hs.LastCheck,
 			&hs.Status,
+			&hs.LastMessage,
+			&hs.Settings,
+			&hs.CertExpiry,
+			&hs.WarningThreshold,
+			&hs.CriticalThreshold,
+			&hs.ResponseTime,
+			&hs.MaxRetries,
+			&hs.RetryInterval,
+			&hs.FailureCount,
+			&hs.FlapDetection,
+			&hs.Flapping,
+			&hs.NotifiedStatus,
+			&hs.AddressFamily,
+			&hs.HeartbeatToken,
+			&hs.LastPingAt,
+			&hs.LastPingStatus,
+			&hs.PingStartedAt,
+			&hs.ActivatedAt,
 			&hs.CreatedAt,
 			&hs.UpdatedAt,
 			&hs.Service.ID,
@@ -265,7 +297,10 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 		serviceQuery := `
 				 select
 	              hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
-	              hs.last_check, hs.status, hs.created_at, hs.updated_at,
+	              hs.last_check, hs.status, hs.last_message, hs.settings, hs.cert_expiry, hs.warning_threshold_ms, hs.critical_threshold_ms,
+	              hs.response_time_ms, hs.max_retries, hs.retry_interval, hs.failure_count,
+		hs.flap_detection, hs.flapping, hs.notified_status, hs.address_family, hs.heartbeat_token, hs.last_ping_at, hs.last_ping_status,
+		hs.ping_started_at, hs.activated_at, hs.created_at, hs.updated_at,
 								s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at
 					  from
 						    host_services hs
@@ -292,6 +327,24 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 				&hs.ScheduleUnit,
 				&hs.LastCheck,
 				&hs.Status,
+				&hs.LastMessage,
+				&hs.Settings,
+				&hs.CertExpiry,
+				&hs.WarningThreshold,
+				&hs.CriticalThreshold,
+				&hs.ResponseTime,
+				&hs.MaxRetries,
+				&hs.RetryInterval,
+				&hs.FailureCount,
+				&hs.FlapDetection,
+				&hs.Flapping,
+				&hs.NotifiedStatus,
+				&hs.AddressFamily,
+				&hs.HeartbeatToken,
+				&hs.LastPingAt,
+				&hs.LastPingStatus,
+				&hs.PingStartedAt,
+				&hs.ActivatedAt,
 				&hs.CreatedAt,
 				&hs.UpdatedAt,
 				&hs.Service.ID,
@@ -334,13 +387,16 @@ func (m *postgresDBRepo) UpdateHostServiceStatus(hostID, serviceID, active int)
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	//Update active column for specific host and service
+	//Update active column for specific host and service, restarting the
+	//activated_at clock when a service is switched on
 	stmt := `
-	     update host_services set active = $1 where host_id = $2 and service_id = $3		
+	     update host_services set active = $1,
+	     activated_at = case when $1 = 1 and active <> 1 then $4 else activated_at end
+	     where host_id = $2 and service_id = $3
 	`
 
 	//Execute update
-	_, err := m.DB.ExecContext(ctx, stmt, active, hostID, serviceID)
+	_, err := m.DB.ExecContext(ctx, stmt, active, hostID, serviceID, time.Now())
 	if err != nil {
 		return err
 	}
@@ -348,7 +404,8 @@ func (m *postgresDBRepo) UpdateHostServiceStatus(hostID, serviceID, active int)
 	return nil
 }
 
-// UpdateHostService updates a host service in the db
+// UpdateHostService updates a host service in the db after a check; settings
+// have their own writer, so a check never overwrites settings saved meanwhile
 func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -358,9 +415,11 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 		      host_services set
 					     host_id = $1, service_id = $2, active = $3,
 							 schedule_number = $4, schedule_unit = $5,
-							 last_check = $6, status = $7, updated_at = $8
+							 last_check = $6, status = $7, last_message = $8,
+							 cert_expiry = $9, response_time_ms = $10,
+							 failure_count = $11, flapping = $12, notified_status = $13, updated_at = $14
 			where
-			    id = $9 	
+			    id = $15 	
 	`
 
 	_, err := m.DB.ExecContext(ctx, stmt,
@@ -371,6 +430,12 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 		hs.ScheduleUnit,
 		hs.LastCheck,
 		hs.Status,
+		hs.LastMessage,
+		hs.CertExpiry,
+		hs.ResponseTime,
+		hs.FailureCount,
+		hs.Flapping,
+		hs.NotifiedStatus,
 		hs.UpdatedAt,
 		hs.ID,
 	)
@@ -380,8 +445,113 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 	return nil
 }
 
-// GetAllServiceStatusCounts returns the counts of host services by status (pending, healthy, warning, problem)
-func (m *postgresDBRepo) GetAllServiceStatusCounts() (int, int, int, int, error) {
+// UpdateHostServiceSettings stores the checker settings for a host service
+func (m *postgresDBRepo) UpdateHostServiceSettings(id int, settings string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set settings = $1, updated_at = $2 where id = $3`
+
+	_, err := m.DB.ExecContext(ctx, stmt, settings, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateHostServiceThresholds stores the response time thresholds, in milliseconds, for a host service
+func (m *postgresDBRepo) UpdateHostServiceThresholds(id, warning, critical int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set warning_threshold_ms = $1, critical_threshold_ms = $2,
+		updated_at = $3 where id = $4`
+
+	_, err := m.DB.ExecContext(ctx, stmt, warning, critical, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateHostServiceRetries stores how many consecutive failures confirm a problem,
+// how many seconds apart those retries run, and whether flap detection is on
+func (m *postgresDBRepo) UpdateHostServiceRetries(id, maxRetries, retryInterval, flapDetection int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set max_retries = $1, retry_interval = $2, flap_detection = $3,
+		updated_at = $4 where id = $5`
+
+	_, err := m.DB.ExecContext(ctx, stmt, maxRetries, retryInterval, flapDetection, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateHostServiceAddressFamily stores whether a host service is checked over
+// IPv4, IPv6, both or whatever the system picks
+func (m *postgresDBRepo) UpdateHostServiceAddressFamily(id int, family string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set address_family = $1, updated_at = $2 where id = $3`
+
+	_, err := m.DB.ExecContext(ctx, stmt, family, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetHostServiceByHeartbeatToken returns the host service a heartbeat token belongs to
+func (m *postgresDBRepo) GetHostServiceByHeartbeatToken(token string) (models.HostService, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `select id from host_services where heartbeat_token = $1 and heartbeat_token <> ''`
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, query, token).Scan(&id)
+	if err != nil {
+		return models.HostService{}, err
+	}
+
+	return m.GetHostServiceByID(id)
+}
+
+// UpdateHeartbeatToken stores the secret token a heartbeat host service is pinged with
+func (m *postgresDBRepo) UpdateHeartbeatToken(id int, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set heartbeat_token = $1, updated_at = $2 where id = $3`
+
+	_, err := m.DB.ExecContext(ctx, stmt, token, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateHeartbeatPing stores the last ping received by a heartbeat host service
+func (m *postgresDBRepo) UpdateHeartbeatPing(hs models.HostService) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update host_services set last_ping_at = $1, last_ping_status = $2, ping_started_at = $3,
+		updated_at = $4 where id = $5`
+
+	_, err := m.DB.ExecContext(ctx, stmt, hs.LastPingAt, hs.LastPingStatus, hs.PingStartedAt, time.Now(), hs.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAllServiceStatusCounts returns the counts of host services by status (pending, healthy, warning, problem, unknown)
+func (m *postgresDBRepo) GetAllServiceStatusCounts() (int, int, int, int, int, error) {
 	//Set DB timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -392,11 +562,12 @@ func (m *postgresDBRepo) GetAllServiceStatusCounts() (int, int, int, int, error)
 					   (select count(id) from host_services where active = 1 and status = 'pending') as pending,
 						 (select count(id) from host_services where active = 1 and status = 'healthy') as healthy,
 						 (select count(id) from host_services where active = 1 and status = 'warning') as warning,
-						 (select count(id) from host_services where active = 1 and status = 'problem') as problem
+						 (select count(id) from host_services where active = 1 and status = 'problem') as problem,
+						 (select count(id) from host_services where active = 1 and status = 'unknown') as unknown
 	
 	`
 
-	var pending, healthy, warning, problem int
+	var pending, healthy, warning, problem, unknown int
 
 	// execute query and scan counts into variables
 	row := m.DB.QueryRowContext(ctx, query)
@@ -405,12 +576,13 @@ func (m *postgresDBRepo) GetAllServiceStatusCounts() (int, int, int, int, error)
 		&healthy,
 		&warning,
 		&problem,
+		&unknown,
 	)
 	if err != nil {
-		return 0, 0, 0, 0, err
+		return 0, 0, 0, 0, 0, err
 	}
 
-	return pending, healthy, warning, problem, nil
+	return pending, healthy, warning, problem, unknown, nil
 }
 
 // GetServicesByStatus returns all active host services with a specific status
@@ -423,7 +595,10 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 	query := `
 	select 
 		hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
-		hs.last_check, hs.status, hs.created_at, hs.updated_at,
+		hs.last_check, hs.status, hs.last_message, hs.settings, hs.cert_expiry, hs.warning_threshold_ms, hs.critical_threshold_ms,
+		hs.response_time_ms, hs.max_retries, hs.retry_interval, hs.failure_count,
+		hs.flap_detection, hs.flapping, hs.notified_status, hs.address_family, hs.heartbeat_token, hs.last_ping_at, hs.last_ping_status,
+		hs.ping_started_at, hs.activated_at, hs.created_at, hs.updated_at,
 		h.host_name, s.service_name
 	from
 		host_services hs
@@ -457,6 +632,24 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 			&h.ScheduleUnit,
 			&h.LastCheck,
 			&h.Status,
+			&h.LastMessage,
+			&h.Settings,
+			&h.CertExpiry,
+			&h.WarningThreshold,
+			&h.CriticalThreshold,
+			&h.ResponseTime,
+			&h.MaxRetries,
+			&h.RetryInterval,
+			&h.FailureCount,
+			&h.FlapDetection,
+			&h.Flapping,
+			&h.NotifiedStatus,
+			&h.AddressFamily,
+			&h.HeartbeatToken,
+			&h.LastPingAt,
+			&h.LastPingStatus,
+			&h.PingStartedAt,
+			&h.ActivatedAt,
 			&h.CreatedAt,
 			&h.UpdatedAt,
 			&h.HostName,
@@ -481,7 +674,10 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostService, error)
 	// Fetch host service joined with service details
 	query := `
   select hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit, 
-	   	 hs.last_check, hs.status, hs.created_at, hs.updated_at, s.id, s.service_name,
+	   	 hs.last_check, hs.status, hs.last_message, hs.settings, hs.cert_expiry, hs.warning_threshold_ms, hs.critical_threshold_ms,
+	   	 hs.response_time_ms, hs.max_retries, hs.retry_interval, hs.failure_count,
+		hs.flap_detection, hs.flapping, hs.notified_status, hs.address_family, hs.heartbeat_token, hs.last_ping_at, hs.last_ping_status,
+		hs.ping_started_at, hs.activated_at, hs.created_at, hs.updated_at, s.id, s.service_name,
 		   s.active, s.icon, s.created_at, s.updated_at, h.host_name
   from host_services hs
 	left join services s on (hs.service_id = s.id)
@@ -504,6 +700,24 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostService, error)
 		&hs.ScheduleUnit,
 		&hs.LastCheck,
 		&hs.Status,
+		&hs.LastMessage,
+		&hs.Settings,
+		&hs.CertExpiry,
+		&hs.WarningThreshold,
+		&hs.CriticalThreshold,
+		&hs.ResponseTime,
+		&hs.MaxRetries,
+		&hs.RetryInterval,
+		&hs.FailureCount,
+		&hs.FlapDetection,
+		&hs.Flapping,
+		&hs.NotifiedStatus,
+		&hs.AddressFamily,
+		&hs.HeartbeatToken,
+		&hs.LastPingAt,
+		&hs.LastPingStatus,
+		&hs.PingStartedAt,
+		&hs.ActivatedAt,
 		&hs.CreatedAt,
 		&hs.UpdatedAt,
 		&hs.Service.ID,
@@ -533,7 +747,10 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 
 	query := `
 		select hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number,
-					hs.schedule_unit, hs.last_check, hs.status, hs.created_at, hs.updated_at,
+					hs.schedule_unit, hs.last_check, hs.status, hs.last_message, hs.settings, hs.cert_expiry, hs.warning_threshold_ms, hs.critical_threshold_ms,
+					hs.response_time_ms, hs.max_retries, hs.retry_interval, hs.failure_count,
+		hs.flap_detection, hs.flapping, hs.notified_status, hs.address_family, hs.heartbeat_token, hs.last_ping_at, hs.last_ping_status,
+		hs.ping_started_at, hs.activated_at, hs.created_at, hs.updated_at,
 					s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at,
 					h.host_name
 		from host_services hs
@@ -569,6 +786,24 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 			&h.ScheduleUnit,
 			&h.LastCheck,
 			&h.Status,
+			&h.LastMessage,
+			&h.Settings,
+			&h.CertExpiry,
+			&h.WarningThreshold,
+			&h.CriticalThreshold,
+			&h.ResponseTime,
+			&h.MaxRetries,
+			&h.RetryInterval,
+			&h.FailureCount,
+			&h.FlapDetection,
+			&h.Flapping,
+			&h.NotifiedStatus,
+			&h.AddressFamily,
+			&h.HeartbeatToken,
+			&h.LastPingAt,
+			&h.LastPingStatus,
+			&h.PingStartedAt,
+			&h.ActivatedAt,
 			&h.CreatedAt,
 			&h.UpdatedAt,
 			&h.Service.ID,
@@ -591,3 +826,45 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 	//Return list of services to monitor
 	return services, nil
 }
+
+// AllServices returns all service types ordered by name
+func (m *postgresDBRepo) AllServices() ([]models.Services, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select id, service_name, active, icon, created_at, updated_at
+		from services order by service_name`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services []models.Services
+
+	for rows.Next() {
+		var s models.Services
+		err := rows.Scan(
+			&s.ID,
+			&s.ServiceName,
+			&s.Active,
+			&s.Icon,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		services = append(services, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return services, nil
+}
@@ -0,0 +1,243 @@
+package dbrepo
+
+import (
+	"context"
+	"log"
+	"time"
+	"vigilate/internal/models"
+)
+
+//Package dbrepo provides the PostgreSQL implementation for outbound webhooks
+//and the log of every delivery attempt made to them
+
+// AllWebhooks returns all webhooks, ordered by name
+func (m *postgresDBRepo) AllWebhooks() ([]models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select id, name, url, secret, events, active, created_at, updated_at
+		from webhooks
+		order by name`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+
+	for rows.Next() {
+		var wh models.Webhook
+		err := rows.Scan(
+			&wh.ID,
+			&wh.Name,
+			&wh.URL,
+			&wh.Secret,
+			&wh.Events,
+			&wh.Active,
+			&wh.CreatedAt,
+			&wh.UpdatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		webhooks = append(webhooks, wh)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhookByID returns a webhook by id
+func (m *postgresDBRepo) GetWebhookByID(id int) (models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select id, name, url, secret, events, active, created_at, updated_at
+		from webhooks
+		where id = $1`
+
+	var wh models.Webhook
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&wh.ID,
+		&wh.Name,
+		&wh.URL,
+		&wh.Secret,
+		&wh.Events,
+		&wh.Active,
+		&wh.CreatedAt,
+		&wh.UpdatedAt,
+	)
+	if err != nil {
+		log.Println(err)
+		return wh, err
+	}
+
+	return wh, nil
+}
+
+// InsertWebhook adds a webhook and returns its ID
+func (m *postgresDBRepo) InsertWebhook(wh models.Webhook) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `
+		insert into webhooks (name, url, secret, events, active, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		returning id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		wh.Name,
+		wh.URL,
+		wh.Secret,
+		wh.Events,
+		wh.Active,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// UpdateWebhook updates a webhook by id
+func (m *postgresDBRepo) UpdateWebhook(wh models.Webhook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `
+		update webhooks set name = $1, url = $2, secret = $3, events = $4, active = $5,
+			updated_at = $6
+		where id = $7`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		wh.Name,
+		wh.URL,
+		wh.Secret,
+		wh.Events,
+		wh.Active,
+		time.Now(),
+		wh.ID,
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWebhook removes a webhook; its delivery log goes with it
+func (m *postgresDBRepo) DeleteWebhook(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `delete from webhooks where id = $1`, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// InsertWebhookDelivery records a single delivery attempt and returns its ID
+func (m *postgresDBRepo) InsertWebhookDelivery(d models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `
+		insert into webhook_deliveries (webhook_id, event, payload, attempt, status_code,
+			success, error, duration_ms, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		returning id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		d.WebhookID,
+		d.Event,
+		d.Payload,
+		d.Attempt,
+		d.StatusCode,
+		d.Success,
+		d.Error,
+		d.Duration.Milliseconds(),
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetWebhookDeliveries returns the most recent delivery attempts for a webhook, newest first
+func (m *postgresDBRepo) GetWebhookDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select id, webhook_id, event, payload, attempt, status_code, success, error,
+			duration_ms, created_at, updated_at
+		from webhook_deliveries
+		where webhook_id = $1
+		order by created_at desc, id desc
+		limit $2`
+
+	rows, err := m.DB.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var durationMS int64
+
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			&d.Payload,
+			&d.Attempt,
+			&d.StatusCode,
+			&d.Success,
+			&d.Error,
+			&durationMS,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		d.Duration = time.Duration(durationMS) * time.Millisecond
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -1,6 +1,9 @@
 package repository
 
-import "vigilate/internal/models"
+import (
+	"time"
+	"vigilate/internal/models"
+)
 
 //Package repository defines the interface for database operations,
 //specifying methods for mananging users, authentication and system preferences
@@ -32,9 +35,35 @@ type DatabaseRepo interface {
 	UpdateHost(h models.Host) error
 	AllHosts() ([]models.Host, error)
 	UpdateHostServiceStatus(hostID, serviceID, active int) error
-	GetAllServiceStatusCounts() (int, int, int, int, error)
+	GetAllServiceStatusCounts() (int, int, int, int, int, error)
 	GetServicesByStatus(status string) ([]models.HostService, error)
 	GetHostServiceByID(id int) (models.HostService, error)
 	UpdateHostService(hs models.HostService) error
+	UpdateHostServiceSettings(id int, settings string) error
+	UpdateHostServiceThresholds(id, warning, critical int) error
+	UpdateHostServiceRetries(id, maxRetries, retryInterval, flapDetection int) error
+	UpdateHostServiceAddressFamily(id int, family string) error
 	GetServicesToMonitor() ([]models.HostService, error)
+	GetHostServiceByHeartbeatToken(token string) (models.HostService, error)
+	UpdateHeartbeatToken(id int, token string) error
+	UpdateHeartbeatPing(hs models.HostService) error
+	AllServices() ([]models.Services, error)
+
+	//Check results
+	InsertCheckResult(cr models.CheckResult) (int, error)
+	GetCheckResults(hostServiceID int, from, to time.Time, limit, offset int) ([]models.CheckResult, int, error)
+	GetRecentCheckStatuses(hostServiceID, limit int) ([]string, error)
+
+	//Events
+	InsertEvent(e models.Event) (int, error)
+	GetEvents(filter models.EventFilter, limit, offset int) ([]models.Event, int, error)
+
+	//Webhooks
+	AllWebhooks() ([]models.Webhook, error)
+	GetWebhookByID(id int) (models.Webhook, error)
+	InsertWebhook(wh models.Webhook) (int, error)
+	UpdateWebhook(wh models.Webhook) error
+	DeleteWebhook(id int) error
+	InsertWebhookDelivery(d models.WebhookDelivery) (int, error)
+	GetWebhookDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error)
 }
@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter caps how many messages each number receives within a sliding window
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   map[string][]time.Time
+}
+
+// NewRateLimiter allows limit messages per number within window
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		sent:   make(map[string][]time.Time),
+	}
+}
+
+// SetLimit changes the number of messages allowed per window
+func (l *RateLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// Allow reports whether another message may go to number now, and if so records it
+// A limit of zero or less disables limiting
+func (l *RateLimiter) Allow(number string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.limit <= 0 {
+		return true
+	}
+
+	//Drop sends that have left the window
+	recent := l.sent[number][:0]
+	for _, t := range l.sent[number] {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.sent[number] = recent
+		return false
+	}
+
+	l.sent[number] = append(recent, now)
+	return true
+}
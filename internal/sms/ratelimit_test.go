@@ -0,0 +1,37 @@
+package sms
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(2, 50*time.Millisecond)
+
+	steps := []struct {
+		number string
+		want   bool
+	}{
+		{"+15550001", true},
+		{"+15550001", true},
+		{"+15550001", false},
+		{"+15550002", true},
+	}
+	for i, s := range steps {
+		if got := l.Allow(s.number); got != s.want {
+			t.Errorf("step %d: Allow(%s) = %v, want %v", i+1, s.number, got, s.want)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !l.Allow("+15550001") {
+		t.Error("not allowed again once the window has passed")
+	}
+
+	l.SetLimit(0)
+	for i := 0; i < 5; i++ {
+		if !l.Allow("+15550001") {
+			t.Fatal("a limit of zero should not limit")
+		}
+	}
+}
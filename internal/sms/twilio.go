@@ -0,0 +1,97 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//Package sms sends text message notifications. Twilio talks to the Twilio
+//Messages REST API; the base URL is configurable so that a compatible
+//provider or a local fake can stand in for it
+
+// DefaultTwilioURL is the base URL of the Twilio REST API
+const DefaultTwilioURL = "https://api.twilio.com"
+
+// maxMessageLength keeps messages within a single SMS segment
+const maxMessageLength = 160
+
+// Twilio sends messages through the Twilio Messages API
+type Twilio struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+}
+
+// twilioError is the error body returned by the Twilio API
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewTwilio returns a Twilio sender; an empty baseURL uses DefaultTwilioURL
+func NewTwilio(baseURL, accountSID, authToken, from string) *Twilio {
+	if baseURL == "" {
+		baseURL = DefaultTwilioURL
+	}
+	return &Twilio{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts a single message to the given number
+func (t *Twilio) Send(ctx context.Context, to, body string) error {
+	if t.AccountSID == "" || t.AuthToken == "" || t.From == "" {
+		return fmt.Errorf("sms: twilio is not configured")
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.BaseURL, url.PathEscape(t.AccountSID))
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.From)
+	form.Set("Body", Truncate(body))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr twilioError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("sms: twilio returned %s: %s (code %d)", resp.Status, apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("sms: twilio returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Truncate shortens a message to fit in a single SMS segment
+func Truncate(body string) string {
+	r := []rune(body)
+	if len(r) <= maxMessageLength {
+		return body
+	}
+	return string(r[:maxMessageLength-3]) + "..."
+}
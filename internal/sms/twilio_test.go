@@ -0,0 +1,68 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwilioSend(t *testing.T) {
+	var got http.Header
+	var form map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			http.NotFound(w, r)
+			return
+		}
+		user, pass, _ := r.BasicAuth()
+		if user != "AC123" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code": 20003, "message": "Authenticate"}`))
+			return
+		}
+		got = r.Header
+		r.ParseForm()
+		form = map[string]string{"To": r.PostForm.Get("To"), "From": r.PostForm.Get("From"), "Body": r.PostForm.Get("Body")}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	err := NewTwilio(srv.URL, "AC123", "token", "+15550000").Send(context.Background(), "+15550001", "service down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("content type %q", got.Get("Content-Type"))
+	}
+	want := map[string]string{"To": "+15550001", "From": "+15550000", "Body": "service down"}
+	for k, v := range want {
+		if form[k] != v {
+			t.Errorf("%s = %q, want %q", k, form[k], v)
+		}
+	}
+
+	err = NewTwilio(srv.URL, "AC123", "wrong", "+15550000").Send(context.Background(), "+15550001", "service down")
+	if err == nil || !strings.Contains(err.Error(), "Authenticate (code 20003)") {
+		t.Errorf("got %v, want the Twilio error", err)
+	}
+
+	err = NewTwilio(srv.URL, "", "", "").Send(context.Background(), "+15550001", "service down")
+	if err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("got %v, want not configured", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	short := "service down"
+	if got := Truncate(short); got != short {
+		t.Errorf("Truncate(%q) = %q", short, got)
+	}
+
+	long := strings.Repeat("é", 200)
+	got := []rune(Truncate(long))
+	if len(got) != maxMessageLength || !strings.HasSuffix(string(got), "...") {
+		t.Errorf("Truncate of 200 runes gave %d runes ending %q", len(got), string(got[len(got)-3:]))
+	}
+}
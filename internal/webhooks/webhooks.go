@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+//Package webhooks posts JSON payloads to user defined endpoints. Every request
+//is signed with HMAC-SHA256 of the body using the webhook secret, and failed
+//deliveries are retried with exponential backoff
+
+// Headers sent with every delivery
+const (
+	SignatureHeader = "X-Vigilate-Signature"
+	EventHeader     = "X-Vigilate-Event"
+	DeliveryHeader  = "X-Vigilate-Delivery"
+)
+
+// Default retry policy
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+)
+
+// Attempt describes a single try at delivering a payload
+type Attempt struct {
+	Number     int
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// Success reports whether the endpoint accepted the payload
+func (a Attempt) Success() bool {
+	return a.Err == nil
+}
+
+// Sender delivers payloads, retrying failures with exponential backoff
+type Sender struct {
+	Client      *http.Client
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// NewSender returns a sender with the default retry policy
+func NewSender() *Sender {
+	return &Sender{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+	}
+}
+
+// Sign returns the signature header value for body: sha256=<hex hmac>
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateURL reports whether raw can receive deliveries: an absolute http or
+// https URL with a host
+func ValidateURL(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return errors.New("a URL is required")
+	}
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("the URL must start with http:// or https://")
+	}
+	if u.Host == "" {
+		return errors.New("the URL has no host")
+	}
+	return nil
+}
+
+// Matches reports whether a webhook with the given comma separated event filter
+// wants a notification for status; an empty filter or * matches everything
+func Matches(events, status string) bool {
+	events = strings.TrimSpace(events)
+	if events == "" || events == "*" {
+		return true
+	}
+	for _, e := range strings.Split(events, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" || strings.EqualFold(e, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// Deliver posts body to url until it succeeds or the attempts run out, waiting
+// Backoff, then twice as long, and so on between tries
+// record, if not nil, is called after every attempt
+func (s *Sender) Deliver(ctx context.Context, url, secret, event string, body []byte, record func(Attempt)) error {
+	maxAttempts := s.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	deliveryID := newDeliveryID()
+	wait := s.Backoff
+
+	var err error
+	for n := 1; n <= maxAttempts; n++ {
+		a := s.attempt(ctx, url, secret, event, deliveryID, body)
+		a.Number = n
+		if record != nil {
+			record(a)
+		}
+		if a.Success() {
+			return nil
+		}
+		err = a.Err
+
+		if n == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+
+	return fmt.Errorf("webhooks: giving up on %s after %d attempts: %w", url, maxAttempts, err)
+}
+
+// attempt makes a single signed request; anything but a 2xx response is a failure
+func (s *Sender) attempt(ctx context.Context, url, secret, event, deliveryID string, body []byte) Attempt {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Attempt{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Vigilate-Webhook")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Attempt{Duration: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	a := Attempt{StatusCode: resp.StatusCode, Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Err = fmt.Errorf("webhooks: %s returned %s", url, resp.Status)
+	}
+	return a
+}
+
+// newDeliveryID returns a random id shared by all attempts of one delivery
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
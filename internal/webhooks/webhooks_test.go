@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	//Well known HMAC-SHA256 test value
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		events, status string
+		want           bool
+	}{
+		{"", "problem", true},
+		{"*", "healthy", true},
+		{"problem", "problem", true},
+		{"warning, problem", "problem", true},
+		{"Problem", "problem", true},
+		{"warning,problem", "healthy", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.events, tt.status); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.events, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://hooks.example.com/vigilate", false},
+		{"http://192.0.2.1:8080/hook?token=x", false},
+		{"", true},
+		{"  ", true},
+		{"/relative/path", true},
+		{"hooks.example.com/vigilate", true},
+		{"ftp://hooks.example.com/vigilate", true},
+		{"https://", true},
+		{"http://[::1", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateURL(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	body := []byte(`{"event":"status_changed"}`)
+
+	var mu sync.Mutex
+	var deliveries []string
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		deliveries = append(deliveries, r.Header.Get(DeliveryHeader))
+
+		got, _ := io.ReadAll(r.Body)
+		if sig := r.Header.Get(SignatureHeader); sig != Sign("secret", got) {
+			t.Errorf("signature %s does not match the body", sig)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Sender{Client: srv.Client(), MaxAttempts: 5, Backoff: time.Millisecond}
+	var attempts []Attempt
+	err := s.Deliver(context.Background(), srv.URL, "secret", "status_changed", body, func(a Attempt) {
+		attempts = append(attempts, a)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 || attempts[2].StatusCode != http.StatusOK || attempts[0].Success() {
+		t.Errorf("attempts %+v, want two failures then a success", attempts)
+	}
+	if deliveries[0] == "" || deliveries[0] != deliveries[2] {
+		t.Errorf("delivery ids %v, want one id for every attempt", deliveries)
+	}
+
+	//Keep failing
+	s.MaxAttempts = 2
+	mu.Lock()
+	calls = -10
+	mu.Unlock()
+	if err := s.Deliver(context.Background(), srv.URL, "secret", "status_changed", body, nil); err == nil {
+		t.Error("no error after running out of attempts")
+	}
+}